@@ -0,0 +1,205 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yhat/scrape"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// AccessibilityIssue is an accessibility problem of an element of a page.
+// Path locates the element, like html > body > div:nth-of-type(2) > img.
+type AccessibilityIssue struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Path    string `json:"path"`
+}
+
+// nonDescriptiveTexts are link texts that don't tell where the link goes.
+var nonDescriptiveTexts = map[string]bool{
+	"click here": true,
+	"here":       true,
+	"click":      true,
+	"link":       true,
+	"more":       true,
+	"read more":  true,
+	"learn more": true,
+	"this":       true,
+}
+
+// unlabelledInputTypes are the input types that don't need a label.
+var unlabelledInputTypes = map[string]bool{
+	"hidden": true,
+	"submit": true,
+	"reset":  true,
+	"button": true,
+	"image":  true,
+}
+
+// LintAccessibility statically checks the DOM of a page for images without
+// alt text, links with no or non-descriptive text, a missing lang attribute,
+// form fields without labels and skipped heading levels.
+func LintAccessibility(root *html.Node) []AccessibilityIssue {
+	var issues []AccessibilityIssue
+	add := func(rule string, node *html.Node, format string, args ...interface{}) {
+		issues = append(issues, AccessibilityIssue{Rule: rule, Message: fmt.Sprintf(format, args...), Path: domPath(node)})
+	}
+
+	if htmlNode, ok := scrape.Find(root, scrape.ByTag(atom.Html)); ok && strings.TrimSpace(scrape.Attr(htmlNode, "lang")) == "" {
+		add("html-lang", htmlNode, "Page has no lang attribute.")
+	}
+
+	labelled := make(map[string]bool)
+	for _, label := range scrape.FindAll(root, scrape.ByTag(atom.Label)) {
+		if id := scrape.Attr(label, "for"); id != "" {
+			labelled[id] = true
+		}
+	}
+
+	previousHeading := 0
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.DataAtom {
+			case atom.Img:
+				if !hasAttr(node, "alt") {
+					add("img-alt", node, "Image has no alt text.")
+				}
+
+			case atom.A:
+				if scrape.Attr(node, "href") == "" {
+					break
+				}
+				text := strings.ToLower(strings.TrimSpace(accessibleText(node)))
+				switch {
+				case text == "":
+					add("link-text", node, "Link has no text.")
+				case nonDescriptiveTexts[strings.Trim(text, ".…!")]:
+					add("link-text", node, "Link text %q doesn't describe where it goes.", text)
+				}
+
+			case atom.Input, atom.Select, atom.Textarea:
+				if node.DataAtom == atom.Input && unlabelledInputTypes[strings.ToLower(scrape.Attr(node, "type"))] {
+					break
+				}
+				if !hasLabel(node, labelled) {
+					add("input-label", node, "Form field has no label.")
+				}
+
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				level := int(node.Data[1] - '0')
+				if previousHeading > 0 && level > previousHeading+1 {
+					add("heading-order", node, "Heading level skips from h%d to h%d.", previousHeading, level)
+				}
+				previousHeading = level
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return issues
+}
+
+// accessibleText is the text a screen reader announces for node: its
+// aria-label, or its text and the alt text of its images.
+func accessibleText(node *html.Node) string {
+	if label := strings.TrimSpace(scrape.Attr(node, "aria-label")); label != "" {
+		return label
+	}
+
+	text := []string{scrape.Text(node)}
+	for _, img := range scrape.FindAll(node, scrape.ByTag(atom.Img)) {
+		text = append(text, scrape.Attr(img, "alt"))
+	}
+
+	return strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+}
+
+// hasLabel tells whether the form field node is labelled by a label element,
+// by one of its ancestors or by an ARIA or title attribute.
+func hasLabel(node *html.Node, labelled map[string]bool) bool {
+	if id := scrape.Attr(node, "id"); id != "" && labelled[id] {
+		return true
+	}
+
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(scrape.Attr(node, attr)) != "" {
+			return true
+		}
+	}
+
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.DataAtom == atom.Label {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAttr(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// domPath is the path of element tags from the root to node, with the
+// position among siblings of the same tag when there are several.
+func domPath(node *html.Node) string {
+	var steps []string
+	for n := node; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		step := n.Data
+
+		index, count := 1, 1
+		if n.Parent != nil {
+			count = 0
+			for sibling := n.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == n.Data {
+					count++
+					if sibling == n {
+						index = count
+					}
+				}
+			}
+		}
+		if count > 1 {
+			step = fmt.Sprintf("%s:nth-of-type(%d)", step, index)
+		}
+
+		steps = append([]string{step}, steps...)
+	}
+
+	return strings.Join(steps, " > ")
+}
+
+// AccessibilityRule reports the accessibility issues of linted pages in an
+// audit. It finds nothing unless the crawler linted pages.
+type AccessibilityRule struct{}
+
+func (rule AccessibilityRule) Name() string { return "accessibility" }
+
+func (rule AccessibilityRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, u := range graph.URLs() {
+		for _, issue := range graph.Pages[u].Site.Accessibility {
+			severity := SeverityWarning
+			if issue.Rule == "heading-order" {
+				severity = SeverityInfo
+			}
+			findings = append(findings, Finding{Severity: severity, URL: u, Message: fmt.Sprintf("%s At %s.", issue.Message, issue.Path)})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestLintAccessibility(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []AccessibilityIssue
+	}{
+		{
+			"accessible page",
+			`<html lang="en"><body>
+				<h1>Monzo</h1><h2>Accounts</h2><h3>Joint</h3><h2>Savings</h2>
+				<img src="logo.png" alt="Monzo"><img src="line.png" alt="">
+				<a href="/about">About us</a>
+				<a href="/"><img src="home.png" alt="Home"></a>
+				<a href="/help" aria-label="Help centre">?</a>
+				<label for="email">Email</label><input id="email">
+				<label>Name <input name="name"></label>
+				<input type="search" aria-label="Search">
+				<input type="hidden" name="token"><input type="submit">
+			</body></html>`,
+			nil,
+		},
+		{
+			"inaccessible page",
+			`<html><body>
+				<h1>Monzo</h1><h3>Accounts</h3>
+				<div><img src="logo.png"></div>
+				<div><a href="/about">Click here</a><a href="/more">Read more...</a><a href="/x"></a></div>
+				<form><input name="email"><textarea></textarea></form>
+			</body></html>`,
+			[]AccessibilityIssue{
+				{Rule: "html-lang", Message: "Page has no lang attribute.", Path: "html"},
+				{Rule: "heading-order", Message: "Heading level skips from h1 to h3.", Path: "html > body > h3"},
+				{Rule: "img-alt", Message: "Image has no alt text.", Path: "html > body > div:nth-of-type(1) > img"},
+				{Rule: "link-text", Message: `Link text "click here" doesn't describe where it goes.`, Path: "html > body > div:nth-of-type(2) > a:nth-of-type(1)"},
+				{Rule: "link-text", Message: `Link text "read more..." doesn't describe where it goes.`, Path: "html > body > div:nth-of-type(2) > a:nth-of-type(2)"},
+				{Rule: "link-text", Message: "Link has no text.", Path: "html > body > div:nth-of-type(2) > a:nth-of-type(3)"},
+				{Rule: "input-label", Message: "Form field has no label.", Path: "html > body > form > input"},
+				{Rule: "input-label", Message: "Form field has no label.", Path: "html > body > form > textarea"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := LintAccessibility(root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintAccessibility() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessibilityRule_Check(t *testing.T) {
+	graph := &Graph{Pages: map[string]*Page{
+		"https://monzo.com": {URL: "https://monzo.com", Site: Site{Accessibility: []AccessibilityIssue{
+			{Rule: "img-alt", Message: "Image has no alt text.", Path: "html > body > img"},
+			{Rule: "heading-order", Message: "Heading level skips from h1 to h3.", Path: "html > body > h3"},
+		}}},
+		"https://monzo.com/about": {URL: "https://monzo.com/about"},
+	}}
+
+	want := []Finding{
+		{Severity: SeverityWarning, URL: "https://monzo.com", Message: "Image has no alt text. At html > body > img."},
+		{Severity: SeverityInfo, URL: "https://monzo.com", Message: "Heading level skips from h1 to h3. At html > body > h3."},
+	}
+	if got := (AccessibilityRule{}).Check(graph); !reflect.DeepEqual(got, want) {
+		t.Errorf("AccessibilityRule.Check() = %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_Crawl_accessibility(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html lang="en"><body><img src="logo.png"></body></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opt  CrawlerOpt
+		want []AccessibilityIssue
+	}{
+		{"not linted by default", CrawlerOpt{}, nil},
+		{"linted", CrawlerOpt{Accessibility: true}, []AccessibilityIssue{{Rule: "img-alt", Message: "Image has no alt text.", Path: "html > body > img"}}},
+		{"streaming links", CrawlerOpt{Accessibility: true, StreamLinks: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, err := NewCrawler(context.Background(), tt.opt).Crawl(context.Background(), CrawlQuery{Site: server.URL}, 0)
+			if err != nil {
+				t.Fatalf("Crawler.Crawl() error = %v", err)
+			}
+			if !reflect.DeepEqual(site.Accessibility, tt.want) {
+				t.Errorf("Crawler.Crawl() accessibility = %v, want %v", site.Accessibility, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package crawler
+
+import "math"
+
+// HubLinks is the number of pages a page links to that makes it a hub.
+var HubLinks = 20
+
+// pageRankDamping is the probability a random surfer follows a link rather
+// than jumping to any page.
+var pageRankDamping = 0.85
+
+// pageRankIterations and pageRankTolerance bound the PageRank computation.
+var pageRankIterations = 100
+var pageRankTolerance = 1e-9
+
+// PageStats are the link analytics of a page. Inbound and Outbound count the
+// distinct pages linking to it and linked from it, ClickDepth is the fewest
+// clicks from the root, or -1 when the root doesn't lead to it, and PageRank
+// its internal PageRank; the PageRanks of a graph sum to 1. Orphan is set for
+// a page linked from a single page, and Hub for a page linking to at least
+// HubLinks pages.
+type PageStats struct {
+	URL        string  `json:"url"`
+	Inbound    int     `json:"inbound"`
+	Outbound   int     `json:"outbound"`
+	ClickDepth int     `json:"click_depth"`
+	PageRank   float64 `json:"pagerank"`
+	Orphan     bool    `json:"orphan,omitempty"`
+	Hub        bool    `json:"hub,omitempty"`
+}
+
+// GraphStats are the link analytics of every page of a graph, with the URLs
+// of its orphan and hub pages, sorted.
+type GraphStats struct {
+	Pages   map[string]*PageStats `json:"pages"`
+	Orphans []string              `json:"orphans,omitempty"`
+	Hubs    []string              `json:"hubs,omitempty"`
+}
+
+// Analyze computes the link analytics of the pages of the graph. Only links
+// between pages of the graph count.
+func (graph *Graph) Analyze() GraphStats {
+	urls := graph.URLs()
+	targets := graph.targets()
+
+	stats := GraphStats{Pages: make(map[string]*PageStats, len(urls))}
+	for _, u := range urls {
+		stats.Pages[u] = &PageStats{URL: u, Outbound: len(targets[u]), ClickDepth: -1}
+	}
+	for _, u := range urls {
+		for _, target := range targets[u] {
+			stats.Pages[target].Inbound++
+		}
+	}
+
+	if root, ok := stats.Pages[graph.Root]; ok {
+		root.ClickDepth = 0
+		queue := []string{graph.Root}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, target := range targets[u] {
+				if page := stats.Pages[target]; page.ClickDepth < 0 {
+					page.ClickDepth = stats.Pages[u].ClickDepth + 1
+					queue = append(queue, target)
+				}
+			}
+		}
+	}
+
+	for u, rank := range pageRank(urls, targets) {
+		stats.Pages[u].PageRank = rank
+	}
+
+	for _, u := range urls {
+		page := stats.Pages[u]
+		if page.Inbound == 1 && u != graph.Root {
+			page.Orphan = true
+			stats.Orphans = append(stats.Orphans, u)
+		}
+		if page.Outbound >= HubLinks {
+			page.Hub = true
+			stats.Hubs = append(stats.Hubs, u)
+		}
+	}
+
+	return stats
+}
+
+// targets are the distinct pages of the graph each page links to, other than
+// itself, in link order.
+func (graph *Graph) targets() map[string][]string {
+	targets := make(map[string][]string, len(graph.Pages))
+	for u, page := range graph.Pages {
+		seen := map[string]bool{u: true}
+		for _, link := range page.Links {
+			if _, ok := graph.Pages[link.URL]; ok && !seen[link.URL] {
+				seen[link.URL] = true
+				targets[u] = append(targets[u], link.URL)
+			}
+		}
+	}
+
+	return targets
+}
+
+// pageRank computes the PageRank of urls by power iteration. The rank of
+// pages without links is spread over every page.
+func pageRank(urls []string, targets map[string][]string) map[string]float64 {
+	n := float64(len(urls))
+	ranks := make(map[string]float64, len(urls))
+	for _, u := range urls {
+		ranks[u] = 1 / n
+	}
+
+	for i := 0; i < pageRankIterations; i++ {
+		dangling := 0.0
+		for _, u := range urls {
+			if len(targets[u]) == 0 {
+				dangling += ranks[u]
+			}
+		}
+
+		next := make(map[string]float64, len(urls))
+		for _, u := range urls {
+			next[u] = (1-pageRankDamping)/n + pageRankDamping*dangling/n
+		}
+		for _, u := range urls {
+			for _, target := range targets[u] {
+				next[target] += pageRankDamping * ranks[u] / float64(len(targets[u]))
+			}
+		}
+
+		delta := 0.0
+		for _, u := range urls {
+			delta += math.Abs(next[u] - ranks[u])
+		}
+		ranks = next
+
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	return ranks
+}
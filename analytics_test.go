@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGraph_Analyze(t *testing.T) {
+	defer func(hubLinks int) { HubLinks = hubLinks }(HubLinks)
+	HubLinks = 3
+
+	link := func(urls ...string) []GraphLink {
+		links := make([]GraphLink, len(urls))
+		for i, u := range urls {
+			links[i] = GraphLink{URL: u}
+		}
+		return links
+	}
+
+	// The root is a hub linking to a, b and c. b links back to the root twice,
+	// to itself and to a page out of the graph, none of which count twice or
+	// at all. Only c links to d, and nothing links to e.
+	graph := &Graph{
+		Root: "/",
+		Pages: map[string]*Page{
+			"/":  {URL: "/", Links: link("/a", "/b", "/c")},
+			"/a": {URL: "/a"},
+			"/b": {URL: "/b", Links: link("/", "/", "/b", "/missing")},
+			"/c": {URL: "/c", Links: link("/d")},
+			"/d": {URL: "/d", Links: link("/a")},
+			"/e": {URL: "/e", Links: link("/a")},
+		},
+	}
+
+	got := graph.Analyze()
+
+	wantPages := map[string]PageStats{
+		"/":  {URL: "/", Inbound: 1, Outbound: 3, ClickDepth: 0, Hub: true},
+		"/a": {URL: "/a", Inbound: 3, Outbound: 0, ClickDepth: 1},
+		"/b": {URL: "/b", Inbound: 1, Outbound: 1, ClickDepth: 1, Orphan: true},
+		"/c": {URL: "/c", Inbound: 1, Outbound: 1, ClickDepth: 1, Orphan: true},
+		"/d": {URL: "/d", Inbound: 1, Outbound: 1, ClickDepth: 2, Orphan: true},
+		"/e": {URL: "/e", Inbound: 0, Outbound: 1, ClickDepth: -1},
+	}
+	total := 0.0
+	for u, want := range wantPages {
+		page := got.Pages[u]
+		total += page.PageRank
+
+		withoutRank := *page
+		withoutRank.PageRank = 0
+		if !reflect.DeepEqual(withoutRank, want) {
+			t.Errorf("Graph.Analyze() page %s = %+v, want %+v", u, withoutRank, want)
+		}
+	}
+	if math.Abs(total-1) > 1e-6 {
+		t.Errorf("Graph.Analyze() PageRanks sum to %v, want 1", total)
+	}
+	if got.Pages["/a"].PageRank <= got.Pages["/e"].PageRank {
+		t.Errorf("Graph.Analyze() PageRank of /a = %v, want more than /e = %v", got.Pages["/a"].PageRank, got.Pages["/e"].PageRank)
+	}
+
+	if want := []string{"/b", "/c", "/d"}; !reflect.DeepEqual(got.Orphans, want) {
+		t.Errorf("Graph.Analyze() orphans = %v, want %v", got.Orphans, want)
+	}
+	if want := []string{"/"}; !reflect.DeepEqual(got.Hubs, want) {
+		t.Errorf("Graph.Analyze() hubs = %v, want %v", got.Hubs, want)
+	}
+}
+
+func Test_pageRank(t *testing.T) {
+	tests := []struct {
+		name    string
+		urls    []string
+		targets map[string][]string
+		want    map[string]float64
+	}{
+		{"no pages", nil, nil, map[string]float64{}},
+		{"cycle", []string{"a", "b"}, map[string][]string{"a": {"b"}, "b": {"a"}}, map[string]float64{"a": 0.5, "b": 0.5}},
+		{"root with two leaves", []string{"a", "b", "c"}, map[string][]string{"a": {"b", "c"}}, map[string]float64{"a": 0.259740, "b": 0.370130, "c": 0.370130}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pageRank(tt.urls, tt.targets)
+			if len(got) != len(tt.want) {
+				t.Fatalf("pageRank() = %v, want %v", got, tt.want)
+			}
+			for u, want := range tt.want {
+				if math.Abs(got[u]-want) > 1e-6 {
+					t.Errorf("pageRank()[%s] = %v, want %v", u, got[u], want)
+				}
+			}
+		})
+	}
+}
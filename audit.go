@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// Severity is how much a finding matters.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// rank orders severities, errors first.
+func (severity Severity) rank() int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Finding is a problem an audit rule found on a page.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	URL      string   `json:"url"`
+	Message  string   `json:"message"`
+}
+
+// AuditRule checks the pages of a crawl graph. Name identifies the rule in
+// its findings.
+type AuditRule interface {
+	Name() string
+	Check(graph *Graph) []Finding
+}
+
+// AuditReport is the findings of an audit, errors first, and how many there
+// are of each severity.
+type AuditReport struct {
+	Root     string           `json:"root"`
+	Pages    int              `json:"pages"`
+	Counts   map[Severity]int `json:"counts"`
+	Findings []Finding        `json:"findings"`
+}
+
+// Audit checks graph with rules, or with DefaultAuditRules when rules is
+// empty.
+func Audit(graph *Graph, rules ...AuditRule) AuditReport {
+	if len(rules) == 0 {
+		rules = DefaultAuditRules()
+	}
+
+	report := AuditReport{
+		Root:     graph.Root,
+		Pages:    len(graph.Pages),
+		Counts:   make(map[Severity]int),
+		Findings: []Finding{},
+	}
+
+	for _, rule := range rules {
+		for _, finding := range rule.Check(graph) {
+			finding.Rule = rule.Name()
+			report.Findings = append(report.Findings, finding)
+			report.Counts[finding.Severity]++
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity.rank() < b.Severity.rank()
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Rule < b.Rule
+	})
+
+	return report
+}
+
+// Count is the number of findings of severity.
+func (report AuditReport) Count(severity Severity) int {
+	return report.Counts[severity]
+}
+
+// WriteJSON writes the report as indented JSON.
+func (report AuditReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteHTML writes the report as a single HTML page with no external assets.
+func (report AuditReport) WriteHTML(w io.Writer) error {
+	if err := auditTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("Failed to render audit report. { %v }", err)
+	}
+
+	return nil
+}
+
+var auditTemplate = template.Must(template.New("audit").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Audit: {{.Root}}</title>
+<style>
+{{style}}
+</style>
+</head>
+<body>
+<h1>Audit: {{.Root}}</h1>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Pages</th><td class="number">{{.Pages}}</td></tr>
+<tr><th>Errors</th><td class="number">{{.Count "error"}}</td></tr>
+<tr><th>Warnings</th><td class="number">{{.Count "warning"}}</td></tr>
+<tr><th>Notices</th><td class="number">{{.Count "info"}}</td></tr>
+</table>
+
+<h2>Findings</h2>
+{{if .Findings -}}
+<table>
+<tr><th>Severity</th><th>Rule</th><th>URL</th><th>Message</th></tr>
+{{range .Findings -}}
+<tr><td><span class="status {{.Severity}}">{{.Severity}}</span></td><td>{{.Rule}}</td><td>{{.URL}}</td><td>{{.Message}}</td></tr>
+{{end -}}
+</table>
+{{- else -}}
+<p class="empty">No findings.</p>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fixedRule finds the same findings on every graph.
+type fixedRule []Finding
+
+func (rule fixedRule) Name() string                 { return "fixed" }
+func (rule fixedRule) Check(graph *Graph) []Finding { return rule }
+
+func TestAudit(t *testing.T) {
+	graph := &Graph{Root: "https://monzo.com", Pages: map[string]*Page{"https://monzo.com": {URL: "https://monzo.com"}}}
+
+	tests := []struct {
+		name  string
+		rules []AuditRule
+		want  AuditReport
+	}{
+		{
+			"no findings",
+			[]AuditRule{fixedRule{}},
+			AuditReport{Root: "https://monzo.com", Pages: 1, Counts: map[Severity]int{}, Findings: []Finding{}},
+		},
+		{
+			"errors first, then by URL",
+			[]AuditRule{
+				fixedRule{
+					{Severity: SeverityInfo, URL: "https://monzo.com/a"},
+					{Severity: SeverityWarning, URL: "https://monzo.com/b"},
+					{Severity: SeverityWarning, URL: "https://monzo.com/a"},
+				},
+				fixedRule{{Severity: SeverityError, URL: "https://monzo.com/c"}},
+			},
+			AuditReport{
+				Root:   "https://monzo.com",
+				Pages:  1,
+				Counts: map[Severity]int{SeverityError: 1, SeverityWarning: 2, SeverityInfo: 1},
+				Findings: []Finding{
+					{Rule: "fixed", Severity: SeverityError, URL: "https://monzo.com/c"},
+					{Rule: "fixed", Severity: SeverityWarning, URL: "https://monzo.com/a"},
+					{Rule: "fixed", Severity: SeverityWarning, URL: "https://monzo.com/b"},
+					{Rule: "fixed", Severity: SeverityInfo, URL: "https://monzo.com/a"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Audit(graph, tt.rules...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Audit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudit_defaultRules(t *testing.T) {
+	report := Audit(seoGraph())
+
+	if got, want := len(report.Findings), 11; got != want {
+		t.Errorf("Audit() found %d findings, want %d: %v", got, want, report.Findings)
+	}
+	if got, want := report.Findings[0].Severity, SeverityWarning; got != want {
+		t.Errorf("Audit() first finding severity = %v, want %v", got, want)
+	}
+}
+
+func TestAuditReport_Write(t *testing.T) {
+	report := Audit(seoGraph())
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("AuditReport.WriteJSON() error = %v", err)
+	}
+	var decoded AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("AuditReport.WriteJSON() wrote invalid JSON. { %v }", err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("AuditReport.WriteJSON() = %v, want %v", decoded, report)
+	}
+
+	buf.Reset()
+	if err := report.WriteHTML(&buf); err != nil {
+		t.Fatalf("AuditReport.WriteHTML() error = %v", err)
+	}
+	for _, want := range []string{
+		`<tr><th>Warnings</th><td class="number">9</td></tr>`,
+		`<tr><td><span class="status warning">warning</span></td><td>click_depth</td><td>https://monzo.com/b</td><td>Page is 4 clicks from the root, more than 3.</td></tr>`,
+	} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("AuditReport.WriteHTML() = %v, want it to contain %v", got, want)
+		}
+	}
+}
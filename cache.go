@@ -0,0 +1,234 @@
+package crawler
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ariefrahmansyah/href"
+	"github.com/prometheus/common/log"
+)
+
+var defaultCacheSize = 10000
+
+// Cache stores crawled sites. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool)
+	Put(ctx context.Context, key string, entry CacheEntry) error
+}
+
+// CacheEntry is a cached site, when it was fetched and how long it stays
+// fresh. A zero TTL never expires.
+type CacheEntry struct {
+	Site      Site          `json:"site"`
+	FetchedAt time.Time     `json:"fetched_at"`
+	TTL       time.Duration `json:"ttl"`
+}
+
+// Expired tells whether the entry is older than its TTL at now.
+func (entry CacheEntry) Expired(now time.Time) bool {
+	return entry.TTL > 0 && now.Sub(entry.FetchedAt) > entry.TTL
+}
+
+// MemoryCache is a Cache that keeps at most size entries in memory, evicting
+// the least recently used.
+type MemoryCache struct {
+	mutex   *sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates a MemoryCache holding size entries.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		mutex:   &sync.Mutex{},
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (cache *MemoryCache) Get(ctx context.Context, key string) (CacheEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	cache.lru.MoveToFront(element)
+
+	return element.Value.(*memoryCacheItem).entry, true
+}
+
+func (cache *MemoryCache) Put(ctx context.Context, key string, entry CacheEntry) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		cache.lru.MoveToFront(element)
+		return nil
+	}
+
+	cache.entries[key] = cache.lru.PushFront(&memoryCacheItem{key: key, entry: entry})
+
+	for cache.lru.Len() > cache.size {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+
+	return nil
+}
+
+// FileCache is a Cache that keeps every entry as a JSON file in a directory,
+// so it survives restarts and can be shared by crawlers. Expired entries are
+// removed when they are read, and by Prune.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates a FileCache in dir, creating the directory if needed.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create cache directory ( %s ). { %v }", dir, err)
+	}
+
+	return &FileCache{dir: dir}, nil
+}
+
+func (cache *FileCache) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(cache.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (cache *FileCache) Get(ctx context.Context, key string) (CacheEntry, bool) {
+	file := cache.path(key)
+
+	entry, ok := readCacheEntry(file)
+	if ok && entry.Expired(time.Now()) {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Errorf("Failed to remove cache entry ( %s ). { %v }", file, err)
+		}
+		return CacheEntry{}, false
+	}
+
+	return entry, ok
+}
+
+func (cache *FileCache) Put(ctx context.Context, key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal cache entry ( %s ). { %v }", key, err)
+	}
+
+	if err := writeFileAtomic(cache.path(key), data); err != nil {
+		return fmt.Errorf("Failed to write cache entry ( %s ). { %v }", key, err)
+	}
+
+	return nil
+}
+
+// Prune removes the entries expired at now and returns how many it removed.
+func (cache *FileCache) Prune(now time.Time) (int, error) {
+	files, err := filepath.Glob(filepath.Join(cache.dir, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to list cache entries ( %s ). { %v }", cache.dir, err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if entry, ok := readCacheEntry(file); !ok || !entry.Expired(now) {
+			continue
+		}
+
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("Failed to remove cache entry ( %s ). { %v }", file, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func readCacheEntry(file string) (CacheEntry, bool) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return CacheEntry{}, false
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// fetchPage visits siteURL for a breadth-first crawl, or rebuilds the page
+// from the cache. Webpages are cached one level deep, with their links as
+// leaves, which is also what Crawl caches for a page one level above its
+// MaxDepth, so both strategies share entries.
+func (crawler *Crawler) fetchPage(ctx context.Context, siteURL *url.URL, depth int) (page, error) {
+	if site, err := crawler.GetSiteFromCache(ctx, siteURL, 1); err == nil {
+		log.Debugf("Already visited. Fetch from cache ( %s )", siteURL)
+		return crawler.cachedPage(ctx, siteURL, site, depth), nil
+	}
+
+	p, err := crawler.visit(ctx, siteURL, depth)
+	if err != nil || !p.webpage {
+		return p, err
+	}
+
+	site := Site{Data: href.NewLink(ctx, siteURL, "", siteURL.String(), depth)}
+	p.annotate(&site)
+	for _, link := range sortedLinks(p.links) {
+		site.Sites = append(site.Sites, Site{Data: link})
+	}
+	if err := crawler.PutSiteToCache(ctx, siteURL, 1, site); err != nil {
+		log.Errorf("Failed to cache page ( %s ). { %v }", siteURL, err)
+	}
+
+	return p, nil
+}
+
+// cachedPage rebuilds a page from its cached site. Its links are given
+// depth+1.
+func (crawler *Crawler) cachedPage(ctx context.Context, siteURL *url.URL, site Site, depth int) page {
+	links := make(map[string]href.Link)
+	for _, s := range site.Sites {
+		crawler.addLink(ctx, links, siteURL, s.Data.Text, s.Data.HREF, depth+1)
+	}
+
+	return page{
+		webpage:       true,
+		links:         links,
+		truncated:     site.Truncated,
+		statusCode:    site.StatusCode,
+		contentType:   site.ContentType,
+		responseTime:  site.ResponseTime,
+		redirects:     site.Redirects,
+		metadata:      site.Metadata,
+		accessibility: site.Accessibility,
+		fingerprint:   site.Fingerprint,
+		mixedContent:  site.MixedContent,
+		etag:          site.ETag,
+		lastModified:  site.LastModified,
+	}
+}
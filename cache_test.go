@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestCacheEntry_Expired(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		entry CacheEntry
+		want  bool
+	}{
+		{"no TTL", CacheEntry{FetchedAt: now.Add(-time.Hour)}, false},
+		{"fresh", CacheEntry{FetchedAt: now.Add(-time.Minute), TTL: time.Hour}, false},
+		{"expired", CacheEntry{FetchedAt: now.Add(-time.Hour), TTL: time.Minute}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Expired(now); got != tt.want {
+				t.Errorf("CacheEntry.Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		size     int
+		puts     []string
+		gets     []string
+		wantKeys []string
+	}{
+		{
+			"under size",
+			2,
+			[]string{"a", "b"},
+			nil,
+			[]string{"a", "b"},
+		},
+		{
+			"least recently put is evicted",
+			2,
+			[]string{"a", "b", "c"},
+			nil,
+			[]string{"b", "c"},
+		},
+		{
+			"least recently used is evicted",
+			2,
+			[]string{"a", "b"},
+			[]string{"a"},
+			[]string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryCache(tt.size)
+			for _, key := range tt.puts {
+				cache.Put(ctx, key, CacheEntry{})
+			}
+			for _, key := range tt.gets {
+				cache.Get(ctx, key)
+			}
+			if len(tt.gets) > 0 {
+				cache.Put(ctx, "z", CacheEntry{})
+			}
+
+			for _, key := range tt.wantKeys {
+				if _, ok := cache.Get(ctx, key); !ok {
+					t.Errorf("MemoryCache.Get(%s) is missing", key)
+				}
+			}
+			if cache.lru.Len() > tt.size {
+				t.Errorf("MemoryCache has %d entries, want at most %d", cache.lru.Len(), tt.size)
+			}
+		})
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "crawler-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache() error = %v", err)
+	}
+
+	entry := CacheEntry{
+		Site: Site{
+			Data: href.Link{Text: "Site 1"},
+			Sites: []Site{
+				Site{Data: href.Link{Text: "Site 2"}},
+			},
+		},
+		FetchedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if _, ok := cache.Get(ctx, "missing"); ok {
+		t.Errorf("FileCache.Get() of a missing key is ok")
+	}
+
+	if err := cache.Put(ctx, "key", entry); err != nil {
+		t.Fatalf("FileCache.Put() error = %v", err)
+	}
+
+	got, ok := cache.Get(ctx, "key")
+	if !ok {
+		t.Fatalf("FileCache.Get() is missing")
+	}
+	if !reflect.DeepEqual(got, entry) {
+		t.Errorf("FileCache.Get() = %v, want %v", got, entry)
+	}
+}
+
+func TestFileCache_expired(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "crawler-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache() error = %v", err)
+	}
+
+	now := time.Now()
+	entries := map[string]CacheEntry{
+		"fresh":   {FetchedAt: now, TTL: time.Hour},
+		"forever": {FetchedAt: now.Add(-24 * time.Hour)},
+		"expired": {FetchedAt: now.Add(-2 * time.Hour), TTL: time.Hour},
+		"stale":   {FetchedAt: now.Add(-3 * time.Hour), TTL: time.Hour},
+	}
+	for key, entry := range entries {
+		if err := cache.Put(ctx, key, entry); err != nil {
+			t.Fatalf("FileCache.Put() error = %v", err)
+		}
+	}
+
+	if _, ok := cache.Get(ctx, "expired"); ok {
+		t.Errorf("FileCache.Get() of an expired entry is ok")
+	}
+	if _, err := os.Stat(cache.path("expired")); !os.IsNotExist(err) {
+		t.Errorf("FileCache.Get() doesn't remove the expired entry. { %v }", err)
+	}
+
+	removed, err := cache.Prune(now)
+	if err != nil {
+		t.Fatalf("FileCache.Prune() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("FileCache.Prune() = %d, want 1", removed)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 2 {
+		t.Errorf("cache files = %v, want the fresh entry and the one without a TTL", files)
+	}
+	for _, key := range []string{"fresh", "forever"} {
+		if _, ok := cache.Get(ctx, key); !ok {
+			t.Errorf("FileCache.Get() of %s is missing", key)
+		}
+	}
+}
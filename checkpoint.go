@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/common/log"
+)
+
+// Resume goes on with the breadth-first crawl saved in the checkpoint file of
+// the crawler. Pages already crawled aren't fetched again. Trap detection
+// starts over, so URLs counted before the checkpoint don't count towards its
+// thresholds.
+func (crawler *Crawler) Resume(ctx context.Context) (CrawlResult, error) {
+	if crawler.checkpointFile == "" {
+		return CrawlResult{}, fmt.Errorf("Crawler has no checkpoint file")
+	}
+
+	state, err := loadCheckpoint(crawler.checkpointFile)
+	if err != nil {
+		return CrawlResult{}, err
+	}
+	log.Infof("Resuming crawl ( %s ) with %d pages crawled and %d in the frontier", state.Root.URL, len(state.Crawled), len(state.Frontier))
+
+	return crawler.crawlFrom(ctx, state)
+}
+
+func loadCheckpoint(file string) (*crawlState, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read checkpoint ( %s ). { %v }", file, err)
+	}
+
+	state := &crawlState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("Failed to parse checkpoint ( %s ). { %v }", file, err)
+	}
+
+	if state.Root.URL == nil {
+		return nil, fmt.Errorf("Checkpoint has no root ( %s )", file)
+	}
+
+	if state.Visited == nil {
+		state.Visited = make(map[string]bool)
+	}
+	if state.Failed == nil {
+		state.Failed = make(map[string]bool)
+	}
+	if state.Crawled == nil {
+		state.Crawled = make(map[string]*crawledPage)
+	}
+
+	return state, nil
+}
+
+// saveCheckpoint writes state to the checkpoint file of the crawler, if it
+// has one. A failed write is logged and the crawl goes on.
+func (crawler *Crawler) saveCheckpoint(state *crawlState) {
+	if crawler.checkpointFile == "" {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Errorf("Failed to marshal checkpoint ( %s ). { %v }", crawler.checkpointFile, err)
+		return
+	}
+
+	if err := writeFileAtomic(crawler.checkpointFile, data); err != nil {
+		log.Errorf("Failed to save checkpoint. { %v }", err)
+		return
+	}
+	log.Debugf("Saved checkpoint ( %s ) with %d pages crawled", crawler.checkpointFile, len(state.Crawled))
+}
+
+// removeCheckpoint deletes the checkpoint file of a finished crawl.
+func (crawler *Crawler) removeCheckpoint() {
+	if crawler.checkpointFile == "" {
+		return
+	}
+
+	if err := os.Remove(crawler.checkpointFile); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to remove checkpoint ( %s ). { %v }", crawler.checkpointFile, err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to file and renames it,
+// so readers never see a partial file.
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file))
+	if err != nil {
+		return fmt.Errorf("Failed to create file ( %s ). { %v }", file, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write file ( %s ). { %v }", file, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write file ( %s ). { %v }", file, err)
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("Failed to write file ( %s ). { %v }", file, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestCrawler_Resume(t *testing.T) {
+	query := CrawlQuery{Site: bfs0.URL, MaxDepth: 3}
+
+	want, err := NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}).CrawlBreadthFirst(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Site = withoutResponseTimes(want.Site)
+
+	root := href.NewLink(context.Background(), bfs0URL, "", bfs0URL.String(), 0)
+	link1 := href.NewLink(context.Background(), bfs0URL, "1", bfs1URL.String(), 1)
+	link2 := href.NewLink(context.Background(), bfs0URL, "2", bfs2URL.String(), 1)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		state    *crawlState
+		crawl    func(crawler *Crawler) error
+		wantHits map[string]int
+	}{
+		{
+			"interrupted before the first page",
+			nil,
+			func(crawler *Crawler) error {
+				_, err := crawler.CrawlBreadthFirst(cancelled, query)
+				return err
+			},
+			map[string]int{
+				bfs0URL.Host: 1,
+				bfs1URL.Host: 1,
+				bfs2URL.Host: 1,
+			},
+		},
+		{
+			"root already crawled",
+			&crawlState{
+				Query:    query,
+				Root:     root,
+				Frontier: []frontierEntry{{Link: link1, Depth: 1, Parent: bfs0URL.String()}, {Link: link2, Depth: 1, Parent: bfs0URL.String()}},
+				Visited:  map[string]bool{bfs0URL.String(): true, bfs1URL.String(): true, bfs2URL.String(): true},
+				Failed:   map[string]bool{},
+				Crawled: map[string]*crawledPage{
+					bfs0URL.String(): &crawledPage{Webpage: true, Node: Site{Data: root, StatusCode: 200, ContentType: "text/html", Metadata: &Metadata{WordCount: 2}}, Links: []href.Link{link1, link2}},
+				},
+			},
+			nil,
+			map[string]int{
+				bfs1URL.Host: 1,
+				bfs2URL.Host: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "crawler-checkpoint")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "checkpoint.json")
+
+			crawler := NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst, CheckpointFile: file})
+
+			if tt.state != nil {
+				crawler.saveCheckpoint(tt.state)
+			}
+			if tt.crawl != nil {
+				if err := tt.crawl(crawler); err == nil {
+					t.Fatalf("crawl error = nil, want interruption")
+				}
+			}
+
+			bfsHitsMutex.Lock()
+			bfsHits = make(map[string]int)
+			bfsHitsMutex.Unlock()
+
+			got, err := crawler.Resume(context.Background())
+			if err != nil {
+				t.Fatalf("Crawler.Resume() error = %v", err)
+			}
+			if got.Site = withoutResponseTimes(got.Site); !reflect.DeepEqual(got, want) {
+				t.Errorf("Crawler.Resume() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(bfsHits, tt.wantHits) {
+				t.Errorf("hits = %v, want %v", bfsHits, tt.wantHits)
+			}
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("checkpoint is not removed after the crawl. { %v }", err)
+			}
+		})
+	}
+}
+
+func TestCrawler_Resume_noCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawler-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{"", filepath.Join(dir, "missing.json")} {
+		crawler := NewCrawler(context.Background(), CrawlerOpt{CheckpointFile: file})
+		if _, err := crawler.Resume(context.Background()); err == nil {
+			t.Errorf("Crawler.Resume() with checkpoint file %q error = nil, want error", file)
+		}
+	}
+}
+
+func TestCrawler_CrawlBreadthFirst_checkpointInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawler-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "checkpoint.json")
+
+	// /second waits for a checkpoint with /first in it, which can only be
+	// saved in the middle of their level.
+	var firstSaved bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/first">1</a><a href="/second">2</a></body></html>`))
+		case "/second":
+			for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && !firstSaved; time.Sleep(10 * time.Millisecond) {
+				if state, err := loadCheckpoint(file); err == nil && state.Crawled["http://"+r.Host+"/first"] != nil {
+					firstSaved = true
+				}
+			}
+			fallthrough
+		default:
+			w.Write([]byte(`<html><body></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	crawler := NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst, Concurrency: 1, CheckpointFile: file})
+	if _, err := crawler.CrawlBreadthFirst(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: 2}); err != nil {
+		t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+	}
+
+	if !firstSaved {
+		t.Errorf("checkpoint with /first is not saved before /second is fetched")
+	}
+}
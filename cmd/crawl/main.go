@@ -0,0 +1,231 @@
+// Command crawl crawls a site from the command line and reports broken links.
+//
+// Usage:
+//
+//	crawl [flags] URL
+//	crawl -checkpoint FILE -resume
+//
+// The result is written to stdout, or to -o, as text or JSON. Progress goes to
+// stderr. crawl exits 1 when the crawl fails, 2 on bad usage and 3 when broken
+// links were found, so it can fail a CI job.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ariefrahmansyah/crawler"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	exitError  = 1
+	exitUsage  = 2
+	exitBroken = 3
+)
+
+// patterns is a flag that may be repeated.
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args, os.Stdout, os.Stderr))
+}
+
+// run crawls as asked by the command line args, writing the result to stdout
+// and everything else to stderr, and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	var include, exclude patterns
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	depth := flags.Int("depth", 2, "maximum depth to crawl")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of each request")
+	concurrency := flags.Int("concurrency", 8, "number of pages fetched at the same time")
+	maxPages := flags.Int("max-pages", 0, "stop after this many pages (0 for no limit)")
+	maxBytes := flags.Int64("max-bytes", 0, "stop after downloading this many bytes (0 for no limit)")
+	maxPagesPerHost := flags.Int("max-pages-per-host", 0, "fetch at most this many pages of each host (0 for no limit)")
+	maxDuration := flags.Duration("max-duration", 0, "stop after this long (0 for no limit)")
+	maxBodySize := flags.Int64("max-body-size", 0, "read at most this many bytes of each page (0 for the default)")
+	traps := flags.Bool("traps", true, "do not follow links that look like crawler traps")
+	metadata := flags.Bool("metadata", true, "extract the title, description, headings and other metadata of pages")
+	accessibility := flags.Bool("accessibility", false, "lint pages for accessibility issues")
+	mixedContent := flags.Bool("mixed-content", false, "look for HTTP resources and links on HTTPS pages")
+	index := flags.String("index", "", "save a full-text search index of the crawled pages to this file")
+	flags.Var(&include, "include", "only follow links matching this regular expression (repeatable)")
+	flags.Var(&exclude, "exclude", "do not follow links matching this regular expression (repeatable)")
+	format := flags.String("format", "text", "output format: "+strings.Join(formats, ", "))
+	output := flags.String("o", "", "write the result to this file instead of stdout")
+	checkpoint := flags.String("checkpoint", "", "save progress to this file so the crawl can be resumed")
+	checkpointInterval := flags.Duration("checkpoint-interval", time.Minute, "how often to save progress")
+	resume := flags.Bool("resume", false, "resume the crawl saved in -checkpoint")
+	progressInterval := flags.Duration("progress", time.Second, "how often to print progress to stderr (0 to disable)")
+	logLevel := flags.String("log-level", "warn", "log level: debug, info, warn or error")
+
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s [flags] URL\n       %s -checkpoint FILE -resume\n\n", args[0], args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	if *resume && *checkpoint == "" || !*resume && flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if !validFormat(*format) {
+		fmt.Fprintf(stderr, "Unknown format ( %s ). Use one of: %s\n", *format, strings.Join(formats, ", "))
+		return exitUsage
+	}
+
+	if err := log.Base().SetLevel(*logLevel); err != nil {
+		fmt.Fprintf(stderr, "Unknown log level ( %s ). { %v }\n", *logLevel, err)
+		return exitUsage
+	}
+
+	crawlerOpt := crawler.CrawlerOpt{
+		HTTPClient:         &http.Client{Timeout: *timeout},
+		Strategy:           crawler.BreadthFirst,
+		Concurrency:        *concurrency,
+		MaxBodySize:        *maxBodySize,
+		CheckpointFile:     *checkpoint,
+		CheckpointInterval: *checkpointInterval,
+		DisableMetadata:    !*metadata,
+		Accessibility:      *accessibility,
+		Fingerprints:       *format == "duplicates",
+		MixedContent:       *mixedContent || *format == "mixed-content",
+	}
+	if *traps {
+		crawlerOpt.Traps = crawler.DefaultTrapOpt
+	}
+	if *index != "" {
+		crawlerOpt.Index = crawler.NewIndex()
+	}
+
+	crawlQuery := crawler.CrawlQuery{
+		Site:            flags.Arg(0),
+		MaxDepth:        *depth,
+		MaxPages:        *maxPages,
+		MaxBytes:        *maxBytes,
+		MaxPagesPerHost: *maxPagesPerHost,
+		MaxDuration:     *maxDuration,
+		Include:         include,
+		Exclude:         exclude,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Fprintln(stderr, "Interrupted. Stopping the crawl")
+		cancel()
+	}()
+
+	crawl := crawler.NewCrawler(ctx, crawlerOpt)
+
+	done := make(chan struct{})
+	if *progressInterval > 0 {
+		go reportProgress(stderr, crawl, *progressInterval, done)
+	}
+
+	var result crawler.CrawlResult
+	var err error
+	if *resume {
+		result, err = crawl.Resume(ctx)
+	} else {
+		result, err = crawl.CrawlBreadthFirst(ctx, crawlQuery)
+	}
+	close(done)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to crawl. { %v }\n", err)
+		if *checkpoint != "" && ctx.Err() != nil {
+			fmt.Fprintf(stderr, "Resume with: %s -checkpoint %s -resume\n", args[0], *checkpoint)
+		}
+		return exitError
+	}
+
+	if err := writeOutput(stdout, *output, *format, result); err != nil {
+		fmt.Fprintf(stderr, "Failed to write result. { %v }\n", err)
+		return exitError
+	}
+
+	if *index != "" {
+		if err := crawlerOpt.Index.Save(*index); err != nil {
+			fmt.Fprintf(stderr, "Failed to save index. { %v }\n", err)
+			return exitError
+		}
+	}
+
+	writeSummary(stderr, result)
+
+	if len(result.Broken) > 0 {
+		return exitBroken
+	}
+
+	return 0
+}
+
+// reportProgress prints the progress of crawl to w every interval until done
+// is closed.
+func reportProgress(w io.Writer, crawl *crawler.Crawler, interval time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			p := crawl.Progress()
+			fmt.Fprintf(w, "%d pages, %d failed, %d queued, %d bytes, %s elapsed\n",
+				p.Pages, p.Failed, p.Queued, p.Bytes, p.Elapsed(now).Round(time.Second))
+		}
+	}
+}
+
+// writeSummary prints what the crawl found and every broken link with the
+// pages linking to it.
+func writeSummary(w io.Writer, result crawler.CrawlResult) {
+	fmt.Fprintf(w, "Crawled %d pages, found %d broken links\n", result.Pages, len(result.Broken))
+	if result.StoppedBy != "" {
+		fmt.Fprintf(w, "Stopped by %s, %d pages left unvisited\n", result.StoppedBy, len(result.Unvisited))
+	}
+
+	for _, broken := range result.Broken {
+		fmt.Fprintf(w, "  %s: %s\n", broken.URL, brokenReason(broken))
+		for _, source := range broken.Sources {
+			fmt.Fprintf(w, "      linked from %s\n", source)
+		}
+	}
+}
+
+func brokenReason(broken crawler.BrokenLink) string {
+	if broken.StatusCode != 0 {
+		return fmt.Sprintf("%d %s", broken.StatusCode, http.StatusText(broken.StatusCode))
+	}
+
+	return broken.Error
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/about">About</a></body></html>`))
+		case "/broken":
+			w.Write([]byte(`<html><body><a href="/missing">Missing</a></body></html>`))
+		case "/about":
+			w.Write([]byte(`<html><body></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{"no url", nil, exitUsage, "", "Usage:"},
+		{"two urls", []string{server.URL, server.URL}, exitUsage, "", "Usage:"},
+		{"resume without checkpoint", []string{"-resume"}, exitUsage, "", "Usage:"},
+		{"unknown flag", []string{"-deep", server.URL}, exitUsage, "", "flag provided but not defined: -deep"},
+		{"unknown format", []string{"-format", "pdf", server.URL}, exitUsage, "", "Unknown format ( pdf )"},
+		{"unknown log level", []string{"-log-level", "loud", server.URL}, exitUsage, "", "Unknown log level ( loud )"},
+		{"failed crawl", []string{closed.URL}, exitError, "", "Failed to crawl."},
+		{"no broken links", []string{server.URL}, 0, server.URL + "/about [200]", "Crawled 2 pages, found 0 broken links"},
+		{"broken links", []string{server.URL + "/broken"}, exitBroken, server.URL + "/broken [200]", server.URL + "/missing: 404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"crawl", "-progress", "0", "-log-level", "fatal"}, tt.args...)
+
+			if got := run(args, &stdout, &stderr); got != tt.want {
+				t.Errorf("run() = %d, want %d. %s", got, tt.want, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("run() stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
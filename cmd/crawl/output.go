@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ariefrahmansyah/crawler"
+)
+
+// formats are the output formats of the result: a tree of its URLs, its JSON
+// and the formats of the library.
+var formats = append([]string{"text", "json"}, crawler.ResultFormatNames()...)
+
+func validFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeOutput writes result to file, or to stdout when file is empty.
+func writeOutput(stdout io.Writer, file, format string, result crawler.CrawlResult) error {
+	if file == "" {
+		return writeResult(stdout, format, result)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("Failed to create output file ( %s ). { %v }", file, err)
+	}
+
+	if err := writeResult(f, format, result); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeResult(w io.Writer, format string, result crawler.CrawlResult) error {
+	if resultFormat, ok := crawler.FindResultFormat(format); ok {
+		return resultFormat.Write(w, result)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+
+	default:
+		buf := bufio.NewWriter(w)
+		writeTree(buf, result.Site, 0)
+		return buf.Flush()
+	}
+}
+
+// writeTree writes a site and its sites, one URL per line indented by depth,
+// with the status of fetched pages.
+func writeTree(w io.Writer, site crawler.Site, depth int) {
+	if site.Data.URL == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s", strings.Repeat("  ", depth), site.Data.URL)
+	if site.StatusCode != 0 {
+		fmt.Fprintf(w, " [%d]", site.StatusCode)
+	}
+	fmt.Fprintln(w)
+
+	for _, s := range site.Sites {
+		writeTree(w, s, depth+1)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/ariefrahmansyah/crawler"
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestWriteTree(t *testing.T) {
+	link := func(rawurl string) href.Link {
+		u, _ := url.Parse(rawurl)
+		return href.NewLink(context.Background(), u, "", u.String(), 0)
+	}
+
+	site := crawler.Site{
+		Data:       link("https://monzo.com"),
+		StatusCode: 200,
+		Sites: []crawler.Site{
+			{
+				Data:       link("https://monzo.com/about"),
+				StatusCode: 200,
+				Sites: []crawler.Site{
+					{Data: link("https://monzo.com/team")},
+				},
+			},
+			{Data: link("https://monzo.com/missing"), StatusCode: 404},
+			{},
+		},
+	}
+
+	want := `https://monzo.com [200]
+  https://monzo.com/about [200]
+    https://monzo.com/team
+  https://monzo.com/missing [404]
+`
+
+	var buf bytes.Buffer
+	writeTree(&buf, site, 0)
+	if got := buf.String(); got != want {
+		t.Errorf("writeTree() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+// indexPrevious maps the URL of every fetched page of a previous crawl to its
+// site.
+func indexPrevious(previous *Site) map[string]Site {
+	if previous == nil {
+		return nil
+	}
+
+	index := make(map[string]Site)
+
+	var walk func(site Site)
+	walk = func(site Site) {
+		if site.StatusCode != 0 && site.Data.URL != nil {
+			index[site.Data.URL.String()] = site
+		}
+		for _, s := range site.Sites {
+			walk(s)
+		}
+	}
+	walk(*previous)
+
+	return index
+}
+
+// setConditionalHeaders asks the server to answer 304 Not Modified when the
+// page hasn't changed since the previous crawl.
+func (crawler *Crawler) setConditionalHeaders(req *http.Request, siteURL *url.URL) {
+	previous, ok := crawler.previous[siteURL.String()]
+	if !ok {
+		return
+	}
+
+	if previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+
+	if previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+}
+
+// previousPage rebuilds an unchanged page from the previous crawl. Its links
+// are given depth+1.
+func (crawler *Crawler) previousPage(ctx context.Context, siteURL *url.URL, depth int) page {
+	previous := crawler.previous[siteURL.String()]
+
+	links := make(map[string]href.Link)
+	for _, s := range previous.Sites {
+		crawler.addLink(ctx, links, siteURL, s.Data.Text, s.Data.HREF, depth+1)
+	}
+
+	return page{
+		webpage:       true,
+		links:         links,
+		truncated:     previous.Truncated,
+		statusCode:    previous.StatusCode,
+		contentType:   previous.ContentType,
+		metadata:      previous.Metadata,
+		accessibility: previous.Accessibility,
+		fingerprint:   previous.Fingerprint,
+		mixedContent:  previous.MixedContent,
+		etag:          previous.ETag,
+		lastModified:  previous.LastModified,
+		notModified:   true,
+	}
+}
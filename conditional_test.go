@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCrawler_conditionalCrawl(t *testing.T) {
+	var downloadsMutex sync.Mutex
+	downloads := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v1` + r.URL.Path + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		downloadsMutex.Lock()
+		downloads++
+		downloadsMutex.Unlock()
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body><a href="/a">a</a></body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><body>Last page</body></html>`))
+	}))
+	defer server.Close()
+
+	query := CrawlQuery{Site: server.URL + "/", MaxDepth: 3}
+
+	first, err := NewCrawler(context.Background(), CrawlerOpt{}).CrawlBreadthFirst(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		opt             CrawlerOpt
+		wantDownloads   int
+		wantNotModified bool
+	}{
+		{"without previous crawl", CrawlerOpt{}, 2, false},
+		{"with previous crawl", CrawlerOpt{Previous: &first.Site}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloadsMutex.Lock()
+			downloads = 0
+			downloadsMutex.Unlock()
+
+			got, err := NewCrawler(context.Background(), tt.opt).CrawlBreadthFirst(context.Background(), query)
+			if err != nil {
+				t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+			}
+
+			if downloads != tt.wantDownloads {
+				t.Errorf("downloads = %v, want %v", downloads, tt.wantDownloads)
+			}
+			if got.Site.NotModified != tt.wantNotModified {
+				t.Errorf("Site.NotModified = %v, want %v", got.Site.NotModified, tt.wantNotModified)
+			}
+			if len(got.Site.Sites) != 1 || got.Site.Sites[0].NotModified != tt.wantNotModified {
+				t.Errorf("Site.Sites = %v, want one site with NotModified %v", got.Site.Sites, tt.wantNotModified)
+			}
+		})
+	}
+}
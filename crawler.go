@@ -8,9 +8,11 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ariefrahmansyah/href"
 	"github.com/asaskevich/govalidator"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 	"github.com/yhat/scrape"
 	"golang.org/x/net/html"
@@ -19,36 +21,161 @@ import (
 
 var defaultHTTPClient = &http.Client{}
 var defaultMaxDepth = 2
+var defaultConcurrency = 8
+var defaultMaxBodySize int64 = 10 << 20
 
+// CrawlerOpt configures a Crawler.
 type CrawlerOpt struct {
+	// HTTPClient fetches the pages. A crawler gets a shared client without a
+	// timeout when it is nil.
 	HTTPClient *http.Client
-	MaxDepth   int
+	// MaxDepth is ignored. The depth of a crawl is CrawlQuery.MaxDepth.
+	//
+	// Deprecated: Set CrawlQuery.MaxDepth instead.
+	MaxDepth int
+	// Strategy is the order Crawl visits pages in. Crawl is breadth-first
+	// anyway when the query has limits or a scope, or there is a checkpoint
+	// file.
+	Strategy CrawlStrategy
+	// Concurrency is how many pages a breadth-first crawl fetches at the same
+	// time, 8 when it is 0. Depth-first crawls fetch every link of a page at
+	// the same time.
+	Concurrency int
+	// MaxBodySize caps the bytes read from each page.
+	MaxBodySize int64
+	// StreamLinks extracts links with the HTML tokenizer instead of parsing
+	// the page, which leaves out metadata, accessibility, fingerprints, mixed
+	// content and indexing.
+	StreamLinks bool
+	// Traps enables crawler trap detection.
+	Traps TrapOpt
+	// AddressGuard, when set, keeps the HTTP client away from internal
+	// addresses.
+	AddressGuard *AddressGuard
+	// Registerer, when set, gets the Prometheus collectors of the crawler.
+	Registerer prometheus.Registerer
+	// HostMetrics labels fetch durations with the host fetched. Leave it off
+	// when the hosts crawled aren't bounded, as every host is a new series.
+	HostMetrics bool
+	// Cache keeps crawled sites between crawls for CacheTTL. A crawler gets
+	// its own MemoryCache when it is nil.
+	Cache    Cache
+	CacheTTL time.Duration
+	// Previous is an earlier crawl, used to send conditional requests.
+	Previous *Site
+
+	// CheckpointFile is where a breadth-first crawl saves its progress for
+	// Resume, at most every CheckpointInterval and when it is interrupted.
+	CheckpointFile     string
+	CheckpointInterval time.Duration
+
+	// DisableMetadata skips extracting the metadata of pages.
+	DisableMetadata bool
+	// Accessibility lints pages for accessibility issues.
+	Accessibility bool
+	// Fingerprints fingerprints the text of pages for Graph.Duplicates.
+	Fingerprints bool
+	// MixedContent looks for HTTP resources and links on HTTPS pages.
+	MixedContent bool
+	// Index, when set, gets the text of every page for full-text search.
+	Index *Index
 }
 
 type Crawler struct {
-	httpClient       *http.Client
-	visitedSite      map[string]Site
-	visitedSiteMutex *sync.Mutex
+	httpClient    *http.Client
+	strategy      CrawlStrategy
+	concurrency   int
+	maxBodySize   int64
+	streamLinks   bool
+	metadata      bool
+	accessibility bool
+	fingerprints  bool
+	mixedContent  bool
+	index         *Index
+	traps         *trapDetector
+	metrics       *Metrics
+	hostMetrics   bool
+	cache         Cache
+	cacheTTL      time.Duration
+	previous      map[string]Site
+
+	checkpointFile     string
+	checkpointInterval time.Duration
+
+	progress *progressTracker
 }
 
 func NewCrawler(ctx context.Context, opt CrawlerOpt) *Crawler {
 	crawler := &Crawler{
-		httpClient:       defaultHTTPClient,
-		visitedSite:      make(map[string]Site),
-		visitedSiteMutex: &sync.Mutex{},
+		httpClient:    defaultHTTPClient,
+		strategy:      opt.Strategy,
+		concurrency:   defaultConcurrency,
+		maxBodySize:   defaultMaxBodySize,
+		streamLinks:   opt.StreamLinks,
+		metadata:      !opt.DisableMetadata,
+		accessibility: opt.Accessibility,
+		fingerprints:  opt.Fingerprints,
+		mixedContent:  opt.MixedContent,
+		index:         opt.Index,
+		traps:         newTrapDetector(opt.Traps),
+		cache:         opt.Cache,
+		cacheTTL:      opt.CacheTTL,
+		previous:      indexPrevious(opt.Previous),
+
+		checkpointFile:     opt.CheckpointFile,
+		checkpointInterval: opt.CheckpointInterval,
+
+		progress: newProgressTracker(),
+	}
+
+	if crawler.cache == nil {
+		crawler.cache = NewMemoryCache(defaultCacheSize)
 	}
 
 	if opt.HTTPClient != nil {
 		crawler.httpClient = opt.HTTPClient
 	}
 
+	if opt.Registerer != nil {
+		metrics, err := NewMetrics(opt.Registerer)
+		if err != nil {
+			log.Errorf("Failed to create crawler metrics. { %v }", err)
+		}
+		crawler.metrics = metrics
+		crawler.hostMetrics = opt.HostMetrics
+	}
+
+	if opt.AddressGuard != nil {
+		httpClient := *crawler.httpClient
+		httpClient.Transport = opt.AddressGuard.Transport()
+		crawler.httpClient = &httpClient
+	}
+
+	if opt.Concurrency > 0 {
+		crawler.concurrency = opt.Concurrency
+	}
+
+	if opt.MaxBodySize > 0 {
+		crawler.maxBodySize = opt.MaxBodySize
+	}
+
 	return crawler
 }
 
+// CrawlQuery describes a crawl. MaxPages, MaxBytes, MaxPagesPerHost and
+// MaxDuration are enforced by CrawlBreadthFirst, so Crawl switches to it when
+// any of them is set. So are Include and Exclude, regular expressions a link
+// must match, and must not match, to be followed.
 type CrawlQuery struct {
-	Site     string `valid:"url,required"`
-	MaxDepth int    `valid:"-"`
-	Timeout  int    `valid:"-"`
+	Site            string        `valid:"url,required"`
+	MaxDepth        int           `valid:"-"`
+	Timeout         int           `valid:"-"`
+	MaxPages        int           `valid:"-"`
+	MaxBytes        int64         `valid:"-"`
+	MaxPagesPerHost int           `valid:"-"`
+	MaxDuration     time.Duration `valid:"-"`
+	Include         []string      `valid:"-"`
+	Exclude         []string      `valid:"-"`
 }
 
 func (crawler *Crawler) Crawl(ctx context.Context, query CrawlQuery, depth int) (Site, error) {
@@ -61,6 +188,11 @@ func (crawler *Crawler) Crawl(ctx context.Context, query CrawlQuery, depth int)
 		return Site{}, nil
 	}
 
+	if depth == 0 && (crawler.strategy == BreadthFirst || query.hasLimits() || query.hasScope() || crawler.checkpointFile != "") {
+		result, err := crawler.CrawlBreadthFirst(ctx, query)
+		return result.Site, err
+	}
+
 	if valid, err := crawler.Validate(ctx, query); !valid && err != nil {
 		return Site{}, fmt.Errorf("Query is not valid. { %v }", err)
 	}
@@ -71,40 +203,34 @@ func (crawler *Crawler) Crawl(ctx context.Context, query CrawlQuery, depth int)
 	}
 	log.Debugf("URL to be crawled: %s", siteURL)
 
-	visited, err := crawler.GetSiteFromCache(ctx, siteURL)
+	visited, err := crawler.GetSiteFromCache(ctx, siteURL, query.MaxDepth-depth)
 	if err == nil {
 		log.Debugf("Already visited. Fetch from cache ( %s )", siteURL)
 		return visited, nil
 	}
 
-	resp, err := crawler.Fetch(ctx, siteURL)
+	page, err := crawler.visit(ctx, siteURL, depth)
 	if err != nil {
-		return Site{}, fmt.Errorf("Failed to fetch page ( %s ). { %v }", query.Site, err)
-	}
-	log.Debugf("Response ( %s ): %s", siteURL, resp.Status)
-
-	if resp.Body != nil {
-		defer resp.Body.Close()
+		return Site{}, err
 	}
 
-	if !crawler.IsWebpage(ctx, resp) {
-		log.Debugf("Not a webpage. Do not crawl ( %s )", siteURL)
+	if !page.webpage {
 		return Site{}, nil
 	}
 
-	// Get links on the page
-	links, err := crawler.GetLinks(ctx, siteURL, resp, depth+1)
-	if err != nil {
-		return Site{}, fmt.Errorf("Failed to get links ( %s ). { %v }", query.Site, err)
-	}
-
 	site := Site{
 		mutex: &sync.Mutex{},
 		Data:  href.NewLink(ctx, siteURL, "", siteURL.String(), depth),
 	}
+	page.annotate(&site)
 
 	var wg sync.WaitGroup
-	for _, link := range links {
+	for _, link := range page.links {
+		if reason := crawler.traps.check(link.URL); reason != "" {
+			log.Infof("Looks like a crawler trap ( %s ). Do not crawl ( %s )", reason, link.URL)
+			continue
+		}
+
 		wg.Add(1)
 		go func(site *Site, link href.Link) {
 			defer wg.Done()
@@ -129,11 +255,126 @@ func (crawler *Crawler) Crawl(ctx context.Context, query CrawlQuery, depth int)
 
 	sort.Sort(SitesSorter(site.Sites))
 
-	crawler.PutSiteToCache(ctx, siteURL, site)
+	if err := crawler.PutSiteToCache(ctx, siteURL, query.MaxDepth-depth, site); err != nil {
+		log.Errorf("Failed to cache page ( %s ). { %v }", siteURL, err)
+	}
 
 	return site, nil
 }
 
+// page is the outcome of fetching a single URL.
+type page struct {
+	webpage       bool
+	links         map[string]href.Link
+	bytes         int64
+	truncated     bool
+	statusCode    int
+	contentType   string
+	responseTime  time.Duration
+	redirects     []Redirect
+	metadata      *Metadata
+	accessibility []AccessibilityIssue
+	fingerprint   *Fingerprint
+	mixedContent  []Resource
+	etag          string
+	lastModified  string
+	notModified   bool
+}
+
+// annotate copies what was learned about the page to its site.
+func (p page) annotate(site *Site) {
+	site.Truncated = p.truncated
+	site.StatusCode = p.statusCode
+	site.ContentType = p.contentType
+	site.ResponseTime = p.responseTime
+	site.Redirects = p.redirects
+	site.Metadata = p.metadata
+	site.Accessibility = p.accessibility
+	site.Fingerprint = p.fingerprint
+	site.MixedContent = p.mixedContent
+	site.ETag = p.etag
+	site.LastModified = p.lastModified
+	site.NotModified = p.notModified
+}
+
+// visit fetches siteURL and extracts the links on it. Links are given depth+1.
+func (crawler *Crawler) visit(ctx context.Context, siteURL *url.URL, depth int) (page, error) {
+	start := time.Now()
+	resp, err := crawler.Fetch(ctx, siteURL)
+	responseTime := time.Since(start)
+	if err != nil {
+		var p page
+		if statusErr, ok := err.(*StatusError); ok {
+			p.statusCode = statusErr.StatusCode
+		}
+		return p, fmt.Errorf("Failed to fetch page ( %s ). { %v }", siteURL, err)
+	}
+	log.Debugf("Response ( %s ): %s", siteURL, resp.Status)
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		log.Debugf("Not modified. Reuse previous links ( %s )", siteURL)
+		return crawler.previousPage(ctx, siteURL, depth), nil
+	}
+
+	counted := &countingReadCloser{ReadCloser: resp.Body}
+	body := &limitedReadCloser{ReadCloser: counted, remaining: crawler.maxBodySize}
+	resp.Body = body
+	defer body.Close()
+
+	if !crawler.IsWebpage(ctx, resp) {
+		log.Debugf("Not a webpage. Do not crawl ( %s )", siteURL)
+		return page{
+			statusCode:   resp.StatusCode,
+			contentType:  resp.Header.Get("Content-Type"),
+			responseTime: responseTime,
+			redirects:    redirectChain(resp),
+		}, nil
+	}
+
+	links, root, err := crawler.readPage(ctx, siteURL, resp, depth+1)
+	if err != nil {
+		return page{}, fmt.Errorf("Failed to get links ( %s ). { %v }", siteURL, err)
+	}
+
+	crawler.metrics.addBytes(counted.n)
+
+	if body.truncated {
+		log.Warnf("Body is bigger than %d bytes. Truncated ( %s )", crawler.maxBodySize, siteURL)
+	}
+
+	p := page{
+		webpage:      true,
+		links:        links,
+		bytes:        counted.n,
+		truncated:    body.truncated,
+		statusCode:   resp.StatusCode,
+		contentType:  resp.Header.Get("Content-Type"),
+		responseTime: responseTime,
+		redirects:    redirectChain(resp),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	if root != nil && crawler.metadata {
+		p.metadata = ExtractMetadata(root, siteURL)
+	}
+	if root != nil && crawler.accessibility {
+		p.accessibility = LintAccessibility(root)
+	}
+	if root != nil && crawler.fingerprints {
+		p.fingerprint = ExtractFingerprint(root)
+	}
+	if root != nil && crawler.mixedContent {
+		p.mixedContent = FindMixedContent(root, finalURL(siteURL, resp))
+	}
+	if root != nil && crawler.index != nil {
+		crawler.index.AddPage(siteURL.String(), root)
+	}
+
+	return p, nil
+}
+
 func (crawler *Crawler) Validate(ctx context.Context, query CrawlQuery) (bool, error) {
 	_, err := govalidator.ValidateStruct(query)
 	if err != nil {
@@ -142,42 +383,141 @@ func (crawler *Crawler) Validate(ctx context.Context, query CrawlQuery) (bool, e
 	return true, nil
 }
 
+// StatusError is returned by Fetch for a response other than 200 or 304.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("Failed to get page (%s). { Response status code = %d }", err.URL, err.StatusCode)
+}
+
 func (crawler *Crawler) Fetch(ctx context.Context, siteURL *url.URL) (*http.Response, error) {
 	if siteURL.Scheme == "" {
 		siteURL.Scheme = "http"
 	}
 
-	resp, err := crawler.httpClient.Get(siteURL.String())
+	resp, err := crawler.get(ctx, siteURL)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get page (%s). { %v }", siteURL, err)
 	}
 
-	if resp.StatusCode > http.StatusOK {
-		return nil, fmt.Errorf("Failed to get page (%s). { Response status code = %d }", siteURL, resp.StatusCode)
+	if resp.StatusCode > http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+		return nil, &StatusError{URL: siteURL.String(), StatusCode: resp.StatusCode}
 	}
 
 	return resp, nil
 }
 
-func (crawler *Crawler) GetSiteFromCache(ctx context.Context, siteURL *url.URL) (Site, error) {
-	crawler.visitedSiteMutex.Lock()
-	defer crawler.visitedSiteMutex.Unlock()
+// get sends a single GET request and records it in the metrics.
+func (crawler *Crawler) get(ctx context.Context, siteURL *url.URL) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, siteURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	crawler.setConditionalHeaders(req, siteURL)
+
+	crawler.metrics.addActiveWorkers(1)
+	defer crawler.metrics.addActiveWorkers(-1)
+
+	host := ""
+	if crawler.hostMetrics {
+		host = siteURL.Host
+	}
+
+	start := time.Now()
+	resp, err := crawler.httpClient.Do(req.WithContext(ctx))
+	crawler.metrics.observeFetch(host, resp, err, time.Since(start))
+
+	return resp, err
+}
+
+// cacheKey identifies a site crawled maxDepth levels deep. The same page
+// crawled to another depth has a different tree.
+func cacheKey(siteURL *url.URL, maxDepth int) string {
+	return fmt.Sprintf("%d %s", maxDepth, siteURL)
+}
+
+// cacheKey is the cacheKey of the crawler, which tells apart the sites of
+// crawlers extracting different things from pages.
+func (crawler *Crawler) cacheKey(siteURL *url.URL, maxDepth int) string {
+	if crawler.streamLinks {
+		return cacheKey(siteURL, maxDepth)
+	}
+
+	var extracts []string
+	for _, extract := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"metadata", crawler.metadata},
+		{"accessibility", crawler.accessibility},
+		{"fingerprints", crawler.fingerprints},
+		{"mixed-content", crawler.mixedContent},
+	} {
+		if extract.enabled {
+			extracts = append(extracts, extract.name)
+		}
+	}
+	if len(extracts) == 0 {
+		return cacheKey(siteURL, maxDepth)
+	}
+
+	return cacheKey(siteURL, maxDepth) + " " + strings.Join(extracts, ",")
+}
+
+// indexed tells whether the fetched pages of site are in the index of the
+// crawler, so a cached site can be used without fetching them for it.
+func (crawler *Crawler) indexed(site Site) bool {
+	if crawler.index == nil {
+		return true
+	}
+
+	if site.StatusCode != 0 && site.Data.URL != nil && !crawler.index.Has(site.Data.URL.String()) {
+		return false
+	}
+	for _, s := range site.Sites {
+		if !crawler.indexed(s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetSiteFromCache returns the site of siteURL crawled maxDepth levels deep,
+// unless it is not cached or expired. Sites with pages missing from the index
+// of the crawler don't count as cached, so the pages are fetched and indexed.
+func (crawler *Crawler) GetSiteFromCache(ctx context.Context, siteURL *url.URL, maxDepth int) (Site, error) {
+	entry, ok := crawler.cache.Get(ctx, crawler.cacheKey(siteURL, maxDepth))
+	if ok && entry.Expired(time.Now()) {
+		log.Debugf("Cached page is expired ( %s )", siteURL)
+		ok = false
+	}
+	if ok && !crawler.indexed(entry.Site) {
+		log.Debugf("Cached page is not indexed ( %s )", siteURL)
+		ok = false
+	}
 
-	visited, ok := crawler.visitedSite[siteURL.String()]
+	crawler.metrics.cacheLookup(ok)
 	if ok {
-		return visited, nil
+		return entry.Site, nil
 	}
 
 	return Site{}, fmt.Errorf("Page is not cached yet ( %s )", siteURL)
 }
 
-func (crawler *Crawler) PutSiteToCache(ctx context.Context, siteURL *url.URL, site Site) error {
-	crawler.visitedSiteMutex.Lock()
-	defer crawler.visitedSiteMutex.Unlock()
-
-	crawler.visitedSite[siteURL.String()] = site
+// PutSiteToCache caches the site of siteURL crawled maxDepth levels deep.
+func (crawler *Crawler) PutSiteToCache(ctx context.Context, siteURL *url.URL, maxDepth int, site Site) error {
+	entry := CacheEntry{
+		Site:      site,
+		FetchedAt: time.Now(),
+		TTL:       crawler.cacheTTL,
+	}
 
-	return nil
+	return crawler.cache.Put(ctx, crawler.cacheKey(siteURL, maxDepth), entry)
 }
 
 func (crawler Crawler) IsWebpage(ctx context.Context, resp *http.Response) bool {
@@ -186,39 +526,59 @@ func (crawler Crawler) IsWebpage(ctx context.Context, resp *http.Response) bool
 }
 
 func (crawler Crawler) GetLinks(ctx context.Context, siteURL *url.URL, resp *http.Response, depth int) (map[string]href.Link, error) {
-	links := make(map[string]href.Link)
-
 	// Parse the page.
 	root, err := html.Parse(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	return crawler.linksOf(ctx, siteURL, root, depth), nil
+}
+
+// linksOf finds the links in the DOM of the page at siteURL.
+func (crawler Crawler) linksOf(ctx context.Context, siteURL *url.URL, root *html.Node, depth int) map[string]href.Link {
+	links := make(map[string]href.Link)
+
 	// Search for anchors tag.
 	anchors := scrape.FindAll(root, scrape.ByTag(atom.A))
 
 	for _, anchor := range anchors {
-		aText := scrape.Text(anchor)
-		if aText == "" {
-			continue
-		}
+		crawler.addLink(ctx, links, siteURL, scrape.Text(anchor), scrape.Attr(anchor, "href"), depth)
+	}
 
-		aHref := scrape.Attr(anchor, "href")
-		if aHref == "" {
-			continue
-		}
+	return links
+}
 
-		link := href.NewLink(ctx, siteURL, aText, aHref, depth)
+// readPage gets the links on the page and, unless the crawler streams links,
+// the DOM they were found in.
+func (crawler Crawler) readPage(ctx context.Context, siteURL *url.URL, resp *http.Response, depth int) (map[string]href.Link, *html.Node, error) {
+	if crawler.streamLinks {
+		links, err := crawler.GetLinksStreaming(ctx, siteURL, resp, depth)
+		return links, nil, err
+	}
 
-		if link.IsValidPageLink(ctx) {
-			if href.IsSameDomain(siteURL, link.URL) {
-				log.Debugf("Link to be crawled: %s", link.URL)
-				links[link.URL.String()] = link
-			} else {
-				log.Debugf("Out of domain. Do not crawl: %s", link.HREF)
-			}
-		}
+	root, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return links, nil
+	return crawler.linksOf(ctx, siteURL, root, depth), root, nil
+}
+
+// addLink adds the anchor to links when it points to a page on the same domain.
+func (crawler Crawler) addLink(ctx context.Context, links map[string]href.Link, siteURL *url.URL, aText, aHref string, depth int) {
+	if aText == "" || aHref == "" {
+		return
+	}
+
+	link := href.NewLink(ctx, siteURL, aText, aHref, depth)
+
+	if link.IsValidPageLink(ctx) {
+		if href.IsSameDomain(siteURL, link.URL) {
+			log.Debugf("Link to be crawled: %s", link.URL)
+			links[link.URL.String()] = link
+		} else {
+			log.Debugf("Out of domain. Do not crawl: %s", link.HREF)
+		}
+	}
 }
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ariefrahmansyah/href"
 )
@@ -32,9 +33,31 @@ func TestNewCrawler(t *testing.T) {
 				},
 			},
 			&Crawler{
-				httpClient:       &http.Client{},
-				visitedSite:      make(map[string]Site),
-				visitedSiteMutex: &sync.Mutex{},
+				httpClient:  &http.Client{},
+				concurrency: defaultConcurrency,
+				maxBodySize: defaultMaxBodySize,
+				metadata:    true,
+				cache:       NewMemoryCache(defaultCacheSize),
+				progress:    newProgressTracker(),
+			},
+		},
+		{
+			"breadth first with concurrency",
+			args{
+				context.Background(),
+				CrawlerOpt{
+					Strategy:    BreadthFirst,
+					Concurrency: 2,
+				},
+			},
+			&Crawler{
+				httpClient:  defaultHTTPClient,
+				strategy:    BreadthFirst,
+				concurrency: 2,
+				maxBodySize: defaultMaxBodySize,
+				metadata:    true,
+				cache:       NewMemoryCache(defaultCacheSize),
+				progress:    newProgressTracker(),
 			},
 		},
 	}
@@ -97,8 +120,11 @@ func TestCrawler_Crawl(t *testing.T) {
 				0,
 			},
 			Site{
-				mutex: &sync.Mutex{},
-				Data:  href.NewLink(context.Background(), emptyPageURL, "", emptyPageURL.String(), 0),
+				mutex:       &sync.Mutex{},
+				Data:        href.NewLink(context.Background(), emptyPageURL, "", emptyPageURL.String(), 0),
+				StatusCode:  200,
+				ContentType: "text/html",
+				Metadata:    &Metadata{},
 			},
 			false,
 		},
@@ -113,12 +139,18 @@ func TestCrawler_Crawl(t *testing.T) {
 				0,
 			},
 			Site{
-				mutex: &sync.Mutex{},
-				Data:  href.NewLink(context.Background(), mock0URL, "", mock0URL.String(), 0),
+				mutex:       &sync.Mutex{},
+				Data:        href.NewLink(context.Background(), mock0URL, "", mock0URL.String(), 0),
+				StatusCode:  200,
+				ContentType: "text/html",
+				Metadata:    &Metadata{WordCount: 1},
 				Sites: []Site{
 					Site{
-						mutex: &sync.Mutex{},
-						Data:  href.NewLink(context.Background(), mock01URL, "01", mock01URL.String(), 1),
+						mutex:       &sync.Mutex{},
+						Data:        href.NewLink(context.Background(), mock01URL, "01", mock01URL.String(), 1),
+						StatusCode:  200,
+						ContentType: "text/html",
+						Metadata:    &Metadata{WordCount: 2},
 						Sites: []Site{
 							Site{
 								Data:  href.NewLink(context.Background(), mock011URL, "011", mock011URL.String(), 2),
@@ -142,7 +174,7 @@ func TestCrawler_Crawl(t *testing.T) {
 				t.Errorf("Crawler.Crawl() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
+			if got = withoutResponseTimes(got); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Crawler.Crawl() = %v, want %v", got, tt.want)
 			}
 		})
@@ -229,9 +261,14 @@ func TestCrawler_Fetch(t *testing.T) {
 }
 
 func TestCrawler_GetSiteFromCache(t *testing.T) {
+	cached := NewMemoryCache(defaultCacheSize)
+	cached.Put(context.Background(), cacheKey(parentURL, 2), CacheEntry{Site: defaultSite, FetchedAt: time.Now()})
+	cached.Put(context.Background(), cacheKey(about, 2), CacheEntry{Site: defaultSite, FetchedAt: time.Now().Add(-time.Hour), TTL: time.Minute})
+
 	type args struct {
-		ctx     context.Context
-		siteURL *url.URL
+		ctx      context.Context
+		siteURL  *url.URL
+		maxDepth int
 	}
 	tests := []struct {
 		name    string
@@ -243,12 +280,12 @@ func TestCrawler_GetSiteFromCache(t *testing.T) {
 		{
 			"page not cached",
 			&Crawler{
-				visitedSite:      make(map[string]Site),
-				visitedSiteMutex: &sync.Mutex{},
+				cache: NewMemoryCache(defaultCacheSize),
 			},
 			args{
 				context.Background(),
 				parentURL,
+				2,
 			},
 			Site{},
 			true,
@@ -256,22 +293,46 @@ func TestCrawler_GetSiteFromCache(t *testing.T) {
 		{
 			"page cached",
 			&Crawler{
-				visitedSite: map[string]Site{
-					parentURL.String(): defaultSite,
-				},
-				visitedSiteMutex: &sync.Mutex{},
+				cache: cached,
 			},
 			args{
 				context.Background(),
 				parentURL,
+				2,
 			},
 			defaultSite,
 			false,
 		},
+		{
+			"page cached to another depth",
+			&Crawler{
+				cache: cached,
+			},
+			args{
+				context.Background(),
+				parentURL,
+				3,
+			},
+			Site{},
+			true,
+		},
+		{
+			"page cache expired",
+			&Crawler{
+				cache: cached,
+			},
+			args{
+				context.Background(),
+				about,
+				2,
+			},
+			Site{},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.crawler.GetSiteFromCache(tt.args.ctx, tt.args.siteURL)
+			got, err := tt.crawler.GetSiteFromCache(tt.args.ctx, tt.args.siteURL, tt.args.maxDepth)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Crawler.GetSiteFromCache() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -285,9 +346,10 @@ func TestCrawler_GetSiteFromCache(t *testing.T) {
 
 func TestCrawler_PutSiteToCache(t *testing.T) {
 	type args struct {
-		ctx     context.Context
-		siteURL *url.URL
-		site    Site
+		ctx      context.Context
+		siteURL  *url.URL
+		maxDepth int
+		site     Site
 	}
 	tests := []struct {
 		name    string
@@ -298,12 +360,12 @@ func TestCrawler_PutSiteToCache(t *testing.T) {
 		{
 			"1",
 			&Crawler{
-				visitedSite:      make(map[string]Site),
-				visitedSiteMutex: &sync.Mutex{},
+				cache: NewMemoryCache(defaultCacheSize),
 			},
 			args{
 				context.Background(),
 				parentURL,
+				2,
 				defaultSite,
 			},
 			false,
@@ -311,7 +373,7 @@ func TestCrawler_PutSiteToCache(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.crawler.PutSiteToCache(tt.args.ctx, tt.args.siteURL, tt.args.site); (err != nil) != tt.wantErr {
+			if err := tt.crawler.PutSiteToCache(tt.args.ctx, tt.args.siteURL, tt.args.maxDepth, tt.args.site); (err != nil) != tt.wantErr {
 				t.Errorf("Crawler.PutSiteToCache() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
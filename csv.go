@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var pagesCSVHeader = []string{"url", "depth", "status", "content_type", "response_time_ms", "links", "inbound", "pagerank", "error"}
+
+var linksCSVHeader = []string{"source", "target", "anchor_text", "depth", "status", "content_type", "response_time_ms"}
+
+// WritePagesCSV writes one CSV row per page of the graph, sorted by URL, with
+// its inbound links and PageRank. Status and response time are empty for
+// pages that weren't fetched.
+func (graph *Graph) WritePagesCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(pagesCSVHeader)
+
+	stats := graph.Analyze()
+	for _, u := range graph.URLs() {
+		page := graph.Pages[u]
+		writer.Write([]string{
+			u,
+			strconv.Itoa(page.Depth),
+			csvStatus(page.Site.StatusCode),
+			page.Site.ContentType,
+			csvMilliseconds(page.Site.ResponseTime),
+			strconv.Itoa(len(page.Links)),
+			strconv.Itoa(stats.Pages[u].Inbound),
+			strconv.FormatFloat(stats.Pages[u].PageRank, 'f', 6, 64),
+			page.Error,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteLinksCSV writes one CSV row per link of the graph, sorted by source.
+// Depth, status, content type and response time are those of the target.
+func (graph *Graph) WriteLinksCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(linksCSVHeader)
+
+	for _, u := range graph.URLs() {
+		for _, link := range graph.Pages[u].Links {
+			row := []string{u, link.URL, link.Text, "", "", "", ""}
+			if target, ok := graph.Pages[link.URL]; ok {
+				row[3] = strconv.Itoa(target.Depth)
+				row[4] = csvStatus(target.Site.StatusCode)
+				row[5] = target.Site.ContentType
+				row[6] = csvMilliseconds(target.Site.ResponseTime)
+			}
+			writer.Write(row)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvStatus(status int) string {
+	if status == 0 {
+		return ""
+	}
+	return strconv.Itoa(status)
+}
+
+func csvMilliseconds(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64)
+}
@@ -0,0 +1,37 @@
+package crawler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGraph_WritePagesCSV(t *testing.T) {
+	want := `url,depth,status,content_type,response_time_ms,links,inbound,pagerank,error
+https://monzo.com,0,200,,,2,0,0.259740,
+https://monzo.com/blog/a,1,200,text/html,12.5,0,1,0.370130,
+https://monzo.com/missing,1,404,,,0,1,0.370130,not found
+`
+
+	var buf bytes.Buffer
+	if err := NewResultGraph(exportResult()).WritePagesCSV(&buf); err != nil {
+		t.Fatalf("Graph.WritePagesCSV() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Graph.WritePagesCSV() = %v, want %v", got, want)
+	}
+}
+
+func TestGraph_WriteLinksCSV(t *testing.T) {
+	want := `source,target,anchor_text,depth,status,content_type,response_time_ms
+https://monzo.com,https://monzo.com/blog/a,A post,1,200,text/html,12.5
+https://monzo.com,https://monzo.com/missing,,1,404,,
+`
+
+	var buf bytes.Buffer
+	if err := NewResultGraph(exportResult()).WriteLinksCSV(&buf); err != nil {
+		t.Fatalf("Graph.WriteLinksCSV() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Graph.WriteLinksCSV() = %v, want %v", got, want)
+	}
+}
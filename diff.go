@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// StatusChange is a page whose response status changed between two crawls.
+type StatusChange struct {
+	URL string `json:"url"`
+	Old int    `json:"old"`
+	New int    `json:"new"`
+}
+
+// LinksChange is a page whose outbound links changed between two crawls.
+type LinksChange struct {
+	URL     string   `json:"url"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// SiteDiff is what changed between two crawls. Every list is sorted by URL.
+type SiteDiff struct {
+	Added         []string       `json:"added"`
+	Removed       []string       `json:"removed"`
+	StatusChanged []StatusChange `json:"status_changed"`
+	LinksChanged  []LinksChange  `json:"links_changed"`
+}
+
+// DiffSites compares two site trees. Site trees leave out the pages that
+// failed, so use DiffResults to see pages that broke.
+func DiffSites(old, new Site) SiteDiff {
+	return DiffGraphs(NewGraph(old), NewGraph(new))
+}
+
+// DiffResults compares two crawl results, broken pages included, so a page
+// that started failing shows as a status change.
+func DiffResults(old, new CrawlResult) SiteDiff {
+	return DiffGraphs(NewResultGraph(old), NewResultGraph(new))
+}
+
+// DiffGraphs compares two crawl graphs. Statuses are only compared for pages
+// both crawls tried to fetch, and links for pages both crawls fetched.
+func DiffGraphs(old, new *Graph) SiteDiff {
+	diff := SiteDiff{
+		Added:         []string{},
+		Removed:       []string{},
+		StatusChanged: []StatusChange{},
+		LinksChanged:  []LinksChange{},
+	}
+
+	for _, u := range old.URLs() {
+		if _, ok := new.Pages[u]; !ok {
+			diff.Removed = append(diff.Removed, u)
+		}
+	}
+
+	for _, u := range new.URLs() {
+		newPage := new.Pages[u]
+
+		oldPage, ok := old.Pages[u]
+		if !ok {
+			diff.Added = append(diff.Added, u)
+			continue
+		}
+
+		if !oldPage.tried() || !newPage.tried() {
+			continue
+		}
+
+		if oldPage.Site.StatusCode != newPage.Site.StatusCode {
+			diff.StatusChanged = append(diff.StatusChanged, StatusChange{
+				URL: u,
+				Old: oldPage.Site.StatusCode,
+				New: newPage.Site.StatusCode,
+			})
+		}
+
+		if oldPage.Error != "" || newPage.Error != "" {
+			continue
+		}
+
+		added, removed := diffLinks(oldPage.Links, newPage.Links)
+		if len(added) > 0 || len(removed) > 0 {
+			diff.LinksChanged = append(diff.LinksChanged, LinksChange{URL: u, Added: added, Removed: removed})
+		}
+	}
+
+	return diff
+}
+
+// tried tells whether the crawl fetched the page or failed to. A page that
+// got no response has no status code, only an error.
+func (p *Page) tried() bool {
+	return p.Fetched() || p.Error != ""
+}
+
+// diffLinks returns the sorted link URLs only in new and only in old.
+func diffLinks(old, new []GraphLink) (added, removed []string) {
+	oldURLs := make(map[string]bool)
+	for _, link := range old {
+		oldURLs[link.URL] = true
+	}
+
+	newURLs := make(map[string]bool)
+	for _, link := range new {
+		newURLs[link.URL] = true
+	}
+
+	for u := range newURLs {
+		if !oldURLs[u] {
+			added = append(added, u)
+		}
+	}
+
+	for u := range oldURLs {
+		if !newURLs[u] {
+			removed = append(removed, u)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
+
+// Empty tells whether nothing changed.
+func (diff SiteDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.StatusChanged) == 0 && len(diff.LinksChanged) == 0
+}
+
+// WriteText writes a human readable summary of the diff.
+func (diff SiteDiff) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%d added, %d removed, %d status changed, %d links changed\n",
+		len(diff.Added), len(diff.Removed), len(diff.StatusChanged), len(diff.LinksChanged))
+
+	if len(diff.Added) > 0 {
+		fmt.Fprintf(&buf, "\nAdded pages:\n")
+		for _, u := range diff.Added {
+			fmt.Fprintf(&buf, "  + %s\n", u)
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		fmt.Fprintf(&buf, "\nRemoved pages:\n")
+		for _, u := range diff.Removed {
+			fmt.Fprintf(&buf, "  - %s\n", u)
+		}
+	}
+
+	if len(diff.StatusChanged) > 0 {
+		fmt.Fprintf(&buf, "\nStatus changed:\n")
+		for _, change := range diff.StatusChanged {
+			fmt.Fprintf(&buf, "  ~ %s: %d -> %d\n", change.URL, change.Old, change.New)
+		}
+	}
+
+	if len(diff.LinksChanged) > 0 {
+		fmt.Fprintf(&buf, "\nLinks changed:\n")
+		for _, change := range diff.LinksChanged {
+			fmt.Fprintf(&buf, "  ~ %s\n", change.URL)
+			for _, u := range change.Added {
+				fmt.Fprintf(&buf, "      + %s\n", u)
+			}
+			for _, u := range change.Removed {
+				fmt.Fprintf(&buf, "      - %s\n", u)
+			}
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// String returns the human readable summary of the diff.
+func (diff SiteDiff) String() string {
+	var buf bytes.Buffer
+	diff.WriteText(&buf)
+	return buf.String()
+}
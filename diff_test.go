@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestDiffSites(t *testing.T) {
+	ctx := context.Background()
+	root := href.NewLink(ctx, parentURL, "", parentURL.String(), 0)
+	one := href.NewLink(ctx, parentURL, "1", "/1", 1)
+	two := href.NewLink(ctx, parentURL, "2", "/2", 1)
+	three := href.NewLink(ctx, parentURL, "3", "/3", 1)
+
+	old := Site{
+		Data:       root,
+		StatusCode: 200,
+		Sites: []Site{
+			Site{Data: one, StatusCode: 200},
+			Site{Data: two, StatusCode: 200},
+		},
+	}
+
+	tests := []struct {
+		name string
+		old  Site
+		new  Site
+		want SiteDiff
+	}{
+		{
+			"unchanged",
+			old,
+			old,
+			SiteDiff{
+				Added:         []string{},
+				Removed:       []string{},
+				StatusChanged: []StatusChange{},
+				LinksChanged:  []LinksChange{},
+			},
+		},
+		{
+			"changed",
+			old,
+			Site{
+				Data:       root,
+				StatusCode: 200,
+				Sites: []Site{
+					Site{Data: one, StatusCode: 301},
+					Site{Data: three, StatusCode: 200},
+				},
+			},
+			SiteDiff{
+				Added:   []string{three.URL.String()},
+				Removed: []string{two.URL.String()},
+				StatusChanged: []StatusChange{
+					{URL: one.URL.String(), Old: 200, New: 301},
+				},
+				LinksChanged: []LinksChange{
+					{URL: root.URL.String(), Added: []string{three.URL.String()}, Removed: []string{two.URL.String()}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffSites(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSites() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteDiff_String(t *testing.T) {
+	tests := []struct {
+		name string
+		diff SiteDiff
+		want string
+	}{
+		{
+			"empty",
+			SiteDiff{},
+			"0 added, 0 removed, 0 status changed, 0 links changed\n",
+		},
+		{
+			"changed",
+			SiteDiff{
+				Added:         []string{"https://monzo.com/3"},
+				StatusChanged: []StatusChange{{URL: "https://monzo.com/1", Old: 200, New: 404}},
+				LinksChanged:  []LinksChange{{URL: "https://monzo.com", Removed: []string{"https://monzo.com/2"}}},
+			},
+			`1 added, 0 removed, 1 status changed, 1 links changed
+
+Added pages:
+  + https://monzo.com/3
+
+Status changed:
+  ~ https://monzo.com/1: 200 -> 404
+
+Links changed:
+  ~ https://monzo.com
+      - https://monzo.com/2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.diff.String(); got != tt.want {
+				t.Errorf("SiteDiff.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	var broken int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/about" && atomic.LoadInt32(&broken) == 1 {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/about">About</a></body></html>`))
+	}))
+	defer server.Close()
+
+	crawl := func() CrawlResult {
+		result, err := NewCrawler(context.Background(), CrawlerOpt{}).CrawlBreadthFirst(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: 2})
+		if err != nil {
+			t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+		}
+		return result
+	}
+
+	old := crawl()
+	atomic.StoreInt32(&broken, 1)
+	new := crawl()
+
+	want := SiteDiff{
+		Added:         []string{},
+		Removed:       []string{},
+		StatusChanged: []StatusChange{{URL: server.URL + "/about", Old: 200, New: 404}},
+		LinksChanged:  []LinksChange{},
+	}
+	if got := DiffResults(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffResults() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxDOTLabel is the length anchor texts are cut to in edge labels.
+var maxDOTLabel = 30
+
+// WriteDOT writes the graph in the Graphviz DOT language. Pages are clustered
+// by host and first path segment, and filled by status: green for fetched,
+// blue for redirects, red for errors and grey for pages only linked to.
+func (graph *Graph) WriteDOT(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	fmt.Fprintf(buf, "digraph crawl {\n")
+	fmt.Fprintf(buf, "  rankdir=LR;\n")
+	fmt.Fprintf(buf, "  node [shape=box, style=filled, fontname=\"Helvetica\"];\n")
+
+	clusters := make(map[string][]string)
+	for _, u := range graph.URLs() {
+		prefix := pathPrefix(u)
+		clusters[prefix] = append(clusters[prefix], u)
+	}
+
+	prefixes := make([]string, 0, len(clusters))
+	for prefix := range clusters {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for i, prefix := range prefixes {
+		fmt.Fprintf(buf, "\n  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(buf, "    label=%s;\n", dotQuote(prefix))
+		for _, u := range clusters[prefix] {
+			page := graph.Pages[u]
+			fmt.Fprintf(buf, "    %s [label=%s, fillcolor=%s];\n", dotQuote(u), dotQuote(pageLabel(u)), dotQuote(statusColor(page)))
+		}
+		fmt.Fprintf(buf, "  }\n")
+	}
+
+	fmt.Fprintf(buf, "\n")
+	for _, u := range graph.URLs() {
+		for _, link := range graph.Pages[u].Links {
+			if _, ok := graph.Pages[link.URL]; !ok {
+				continue
+			}
+
+			fmt.Fprintf(buf, "  %s -> %s", dotQuote(u), dotQuote(link.URL))
+			if text := strings.TrimSpace(link.Text); text != "" {
+				fmt.Fprintf(buf, " [label=%s]", dotQuote(truncate(text, maxDOTLabel)))
+			}
+			fmt.Fprintf(buf, ";\n")
+		}
+	}
+
+	fmt.Fprintf(buf, "}\n")
+
+	return buf.Flush()
+}
+
+// pathPrefix is the host and first path segment of u, like example.com/blog.
+func pathPrefix(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	segments := strings.SplitN(strings.Trim(parsed.Path, "/"), "/", 2)
+	if len(segments) < 2 || segments[0] == "" {
+		return parsed.Host + "/"
+	}
+
+	return parsed.Host + "/" + segments[0]
+}
+
+// pageLabel is the path and query of u. Its cluster shows the host.
+func pageLabel(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	return parsed.RequestURI()
+}
+
+// statusColor is the fill color of page. The crawler follows redirects, so
+// redirected pages are told by their Redirects, not their status code.
+func statusColor(page *Page) string {
+	switch {
+	case page.Error != "" || page.Site.StatusCode >= 400:
+		return "salmon"
+	case len(page.Site.Redirects) > 0:
+		return "lightblue"
+	case page.Site.StatusCode != 0:
+		return "palegreen"
+	default:
+		return "lightgrey"
+	}
+}
+
+// dotQuote quotes s as a DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// truncate cuts s to at most n runes, ending it with an ellipsis when cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
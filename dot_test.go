@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+// exportResult is a small crawl with a broken link, shared by the export
+// tests.
+func exportResult() CrawlResult {
+	ctx := context.Background()
+	root := href.NewLink(ctx, parentURL, "", parentURL.String(), 0)
+	post := href.NewLink(ctx, parentURL, "A post", "/blog/a", 1)
+
+	return CrawlResult{
+		Site: Site{
+			Data:       root,
+			StatusCode: 200,
+			Sites: []Site{
+				Site{Data: post, StatusCode: 200, ContentType: "text/html", ResponseTime: 12500 * time.Microsecond},
+			},
+		},
+		Pages: 2,
+		Broken: []BrokenLink{
+			{URL: "https://monzo.com/missing", StatusCode: 404, Error: "not found", Sources: []string{"https://monzo.com"}},
+		},
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	want := `digraph crawl {
+  rankdir=LR;
+  node [shape=box, style=filled, fontname="Helvetica"];
+
+  subgraph cluster_0 {
+    label="monzo.com/";
+    "https://monzo.com" [label="/", fillcolor="palegreen"];
+    "https://monzo.com/missing" [label="/missing", fillcolor="salmon"];
+  }
+
+  subgraph cluster_1 {
+    label="monzo.com/blog";
+    "https://monzo.com/blog/a" [label="/blog/a", fillcolor="palegreen"];
+  }
+
+  "https://monzo.com" -> "https://monzo.com/blog/a" [label="A post"];
+  "https://monzo.com" -> "https://monzo.com/missing";
+}
+`
+
+	var buf bytes.Buffer
+	if err := NewResultGraph(exportResult()).WriteDOT(&buf); err != nil {
+		t.Fatalf("Graph.WriteDOT() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Graph.WriteDOT() = %v, want %v", got, want)
+	}
+}
+
+func Test_pathPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		u    string
+		want string
+	}{
+		{"root", "https://monzo.com", "monzo.com/"},
+		{"top level page", "https://monzo.com/about", "monzo.com/"},
+		{"nested page", "https://monzo.com/blog/2017/a", "monzo.com/blog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathPrefix(tt.u); got != tt.want {
+				t.Errorf("pathPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dotQuote(t *testing.T) {
+	if got, want := dotQuote(`say "hi" \ bye`), `"say \"hi\" \\ bye"`; got != want {
+		t.Errorf("dotQuote() = %v, want %v", got, want)
+	}
+}
+
+func TestGraph_WriteDOT_redirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/new":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body>New</body></html>`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body><a href="/old">Old</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	crawler := NewCrawler(context.Background(), CrawlerOpt{})
+	result, err := crawler.CrawlBreadthFirst(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewResultGraph(result).WriteDOT(&buf); err != nil {
+		t.Fatalf("Graph.WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{
+		fmt.Sprintf(`%q [label="/", fillcolor="palegreen"];`, server.URL),
+		fmt.Sprintf(`%q [label="/old", fillcolor="lightblue"];`, server.URL+"/old"),
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Graph.WriteDOT() = %v, want it to contain %v", buf.String(), want)
+		}
+	}
+}
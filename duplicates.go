@@ -0,0 +1,179 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/yhat/scrape"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultDuplicateThreshold is the SimHash similarity from which two pages
+// are near duplicates: 6 bits out of 64 may differ, while unrelated pages
+// differ by 32 bits on average.
+var DefaultDuplicateThreshold = 0.9
+
+// shingleSize is the number of words of the shingles SimHash is computed
+// from.
+var shingleSize = 3
+
+// Fingerprint identifies the text content of a page. Hash is the SHA-256 of
+// its normalized text, the same for identical pages, and SimHash a 64 bit
+// SimHash of its word shingles, a few bits apart for near identical pages.
+type Fingerprint struct {
+	Hash    string `json:"hash"`
+	SimHash uint64 `json:"simhash"`
+}
+
+// Similarity is the share of SimHash bits fingerprint has in common with
+// other, from 0 to 1.
+func (fingerprint *Fingerprint) Similarity(other *Fingerprint) float64 {
+	return 1 - float64(bits.OnesCount64(fingerprint.SimHash^other.SimHash))/64
+}
+
+// ExtractFingerprint fingerprints the body text of a page, lowercased and
+// without punctuation. It returns nil for a page without text.
+func ExtractFingerprint(root *html.Node) *Fingerprint {
+	body, ok := scrape.Find(root, scrape.ByTag(atom.Body))
+	if !ok {
+		return nil
+	}
+
+	var words []string
+	for _, word := range textWords(body) {
+		if word = normalizeWord(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(words, " ")))
+
+	return &Fingerprint{
+		Hash:    hex.EncodeToString(hash[:]),
+		SimHash: simHash(shingles(words, shingleSize)),
+	}
+}
+
+// shingles are the runs of n consecutive words, or the whole text when it has
+// fewer words.
+func shingles(words []string, n int) []string {
+	if len(words) <= n {
+		return []string{strings.Join(words, " ")}
+	}
+
+	shingles := make([]string, 0, len(words)-n+1)
+	for i := 0; i+n <= len(words); i++ {
+		shingles = append(shingles, strings.Join(words[i:i+n], " "))
+	}
+
+	return shingles
+}
+
+// simHash sets each bit to the majority of that bit in the hashes of the
+// features.
+func simHash(features []string) uint64 {
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		for bit := uint(0); bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit := uint(0); bit < 64; bit++ {
+		if weights[bit] > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
+
+// DuplicateGroup is a set of pages with the same or near the same content.
+// Exact is set when every page has the same text, and Similarity is the
+// lowest similarity between two of its pages.
+type DuplicateGroup struct {
+	URLs       []string `json:"urls"`
+	Exact      bool     `json:"exact"`
+	Similarity float64  `json:"similarity"`
+}
+
+// Duplicates groups the fingerprinted pages of the graph that are identical,
+// or whose similarity is at least threshold. Near duplicates are grouped
+// transitively. Groups are sorted by their first URL. Every pair of pages is
+// compared, so it takes a while on large crawls.
+func (graph *Graph) Duplicates(threshold float64) []DuplicateGroup {
+	var urls []string
+	for _, u := range graph.URLs() {
+		if graph.Pages[u].Site.Fingerprint != nil {
+			urls = append(urls, u)
+		}
+	}
+	fingerprint := func(u string) *Fingerprint { return graph.Pages[u].Site.Fingerprint }
+
+	parent := make(map[string]string, len(urls))
+	var find func(u string) string
+	find = func(u string) string {
+		if parent[u] != u {
+			parent[u] = find(parent[u])
+		}
+		return parent[u]
+	}
+	for _, u := range urls {
+		parent[u] = u
+	}
+
+	for i, a := range urls {
+		for _, b := range urls[i+1:] {
+			if fingerprint(a).Hash == fingerprint(b).Hash || fingerprint(a).Similarity(fingerprint(b)) >= threshold {
+				parent[find(b)] = find(a)
+			}
+		}
+	}
+
+	members := make(map[string][]string)
+	for _, u := range urls {
+		root := find(u)
+		members[root] = append(members[root], u)
+	}
+
+	groups := []DuplicateGroup{}
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+
+		duplicate := DuplicateGroup{URLs: group, Exact: true, Similarity: 1}
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				if fingerprint(a).Hash != fingerprint(b).Hash {
+					duplicate.Exact = false
+				}
+				if similarity := fingerprint(a).Similarity(fingerprint(b)); similarity < duplicate.Similarity {
+					duplicate.Similarity = similarity
+				}
+			}
+		}
+		groups = append(groups, duplicate)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].URLs[0] < groups[j].URLs[0] })
+
+	return groups
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func fingerprintOf(t *testing.T, page string) *Fingerprint {
+	root, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ExtractFingerprint(root)
+}
+
+const duplicatesText = `Monzo is a bank that lives on your smartphone. You can open an account
+	in minutes, get instant notifications when you spend, split bills with friends,
+	set budgets, save money in pots and see all your spending in one place.
+	We are building a bank that works for everyone.`
+
+func TestExtractFingerprint(t *testing.T) {
+	original := fingerprintOf(t, `<html><body><p>`+duplicatesText+`</p></body></html>`)
+
+	tests := []struct {
+		name           string
+		page           string
+		wantNil        bool
+		wantSameHash   bool
+		wantSimilarity float64
+	}{
+		{"no text", `<html><body><script>var a = 1;</script></body></html>`, true, false, 0},
+		{"same text, other markup and case", `<html><head><title>Other</title></head><body><div><b>MONZO</b> ` + strings.TrimPrefix(duplicatesText, "Monzo") + `</div></body></html>`, false, true, 1},
+		{"same text, other punctuation", `<html><body>` + strings.Replace(duplicatesText, ".", "!", -1) + `</body></html>`, false, true, 1},
+		{"one word changed", `<html><body>` + strings.Replace(duplicatesText, "friends", "family", 1) + `</body></html>`, false, false, DefaultDuplicateThreshold},
+		{"other text", `<html><body>The quick brown fox jumps over the lazy dog.</body></html>`, false, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerprintOf(t, tt.page)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("ExtractFingerprint() = %v, want nil", got)
+				}
+				return
+			}
+
+			if sameHash := got.Hash == original.Hash; sameHash != tt.wantSameHash {
+				t.Errorf("ExtractFingerprint() same hash = %v, want %v", sameHash, tt.wantSameHash)
+			}
+			if similarity := got.Similarity(original); tt.wantSimilarity > 0 && similarity < tt.wantSimilarity {
+				t.Errorf("Fingerprint.Similarity() = %v, want at least %v", similarity, tt.wantSimilarity)
+			}
+			if similarity := got.Similarity(original); tt.wantSimilarity == 0 && similarity >= DefaultDuplicateThreshold {
+				t.Errorf("Fingerprint.Similarity() = %v, want less than %v", similarity, DefaultDuplicateThreshold)
+			}
+		})
+	}
+}
+
+func TestGraph_Duplicates(t *testing.T) {
+	page := func(u string, fingerprint *Fingerprint) *Page {
+		return &Page{URL: u, Site: Site{Fingerprint: fingerprint}}
+	}
+
+	graph := &Graph{Pages: map[string]*Page{
+		"/docs/a":      page("/docs/a", &Fingerprint{Hash: "a", SimHash: 0xff00}),
+		"/docs/a.html": page("/docs/a.html", &Fingerprint{Hash: "a", SimHash: 0xff00}),
+		"/docs/b":      page("/docs/b", &Fingerprint{Hash: "b", SimHash: 0xf0f0f0}),
+		"/docs/b-old":  page("/docs/b-old", &Fingerprint{Hash: "b-old", SimHash: 0xf0f0f1}),
+		"/docs/c":      page("/docs/c", &Fingerprint{Hash: "c", SimHash: 0xffffffff00000000}),
+		"/image.png":   page("/image.png", nil),
+		"/empty":       page("/empty", nil),
+	}}
+
+	want := []DuplicateGroup{
+		{URLs: []string{"/docs/a", "/docs/a.html"}, Exact: true, Similarity: 1},
+		{URLs: []string{"/docs/b", "/docs/b-old"}, Exact: false, Similarity: 1 - 1.0/64},
+	}
+	if got := graph.Duplicates(DefaultDuplicateThreshold); !reflect.DeepEqual(got, want) {
+		t.Errorf("Graph.Duplicates() = %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_Crawl_fingerprints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>Hello</body></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opt  CrawlerOpt
+		want bool
+	}{
+		{"not fingerprinted by default", CrawlerOpt{}, false},
+		{"fingerprinted", CrawlerOpt{Fingerprints: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, err := NewCrawler(context.Background(), tt.opt).Crawl(context.Background(), CrawlQuery{Site: server.URL}, 0)
+			if err != nil {
+				t.Fatalf("Crawler.Crawl() error = %v", err)
+			}
+			if got := site.Fingerprint != nil; got != tt.want {
+				t.Errorf("Crawler.Crawl() fingerprinted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
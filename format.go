@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ResultFormat is a format a crawl result can be exported in, besides the
+// JSON of the result itself, with the content type to serve it with.
+type ResultFormat struct {
+	Name        string
+	ContentType string
+	Write       func(w io.Writer, result CrawlResult) error
+}
+
+// ResultFormats are the formats of crawl results, shared by the command line
+// and the web service.
+var ResultFormats = []ResultFormat{
+	{"dot", "text/vnd.graphviz; charset=utf-8", func(w io.Writer, result CrawlResult) error {
+		return NewResultGraph(result).WriteDOT(w)
+	}},
+	{"graphml", "application/graphml+xml; charset=utf-8", func(w io.Writer, result CrawlResult) error {
+		return NewResultGraph(result).WriteGraphML(w)
+	}},
+	{"csv", "text/csv; charset=utf-8", func(w io.Writer, result CrawlResult) error {
+		return NewResultGraph(result).WritePagesCSV(w)
+	}},
+	{"csv-links", "text/csv; charset=utf-8", func(w io.Writer, result CrawlResult) error {
+		return NewResultGraph(result).WriteLinksCSV(w)
+	}},
+	{"html", "text/html; charset=utf-8", WriteReport},
+	{"audit", "application/json", func(w io.Writer, result CrawlResult) error {
+		return Audit(NewResultGraph(result)).WriteJSON(w)
+	}},
+	{"audit-html", "text/html; charset=utf-8", func(w io.Writer, result CrawlResult) error {
+		return Audit(NewResultGraph(result)).WriteHTML(w)
+	}},
+	{"analytics", "application/json", func(w io.Writer, result CrawlResult) error {
+		return writeIndentedJSON(w, NewResultGraph(result).Analyze())
+	}},
+	{"duplicates", "application/json", func(w io.Writer, result CrawlResult) error {
+		return writeIndentedJSON(w, NewResultGraph(result).Duplicates(DefaultDuplicateThreshold))
+	}},
+	{"mixed-content", "application/json", func(w io.Writer, result CrawlResult) error {
+		return writeIndentedJSON(w, NewResultGraph(result).MixedContent())
+	}},
+}
+
+// FindResultFormat returns the format called name.
+func FindResultFormat(name string) (ResultFormat, bool) {
+	for _, format := range ResultFormats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+
+	return ResultFormat{}, false
+}
+
+// ResultFormatNames are the names of ResultFormats, in order.
+func ResultFormatNames() []string {
+	names := make([]string, 0, len(ResultFormats))
+	for _, format := range ResultFormats {
+		names = append(names, format.Name)
+	}
+
+	return names
+}
+
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
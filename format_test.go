@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResultFormats(t *testing.T) {
+	names := make(map[string]bool)
+	for _, format := range ResultFormats {
+		t.Run(format.Name, func(t *testing.T) {
+			if names[format.Name] {
+				t.Errorf("format %s is listed twice", format.Name)
+			}
+			names[format.Name] = true
+
+			if format.ContentType == "" {
+				t.Errorf("format %s has no content type", format.Name)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Write(&buf, exportResult()); err != nil {
+				t.Fatalf("%s Write() error = %v", format.Name, err)
+			}
+			if buf.Len() == 0 {
+				t.Errorf("%s Write() wrote nothing", format.Name)
+			}
+		})
+	}
+}
+
+func TestFindResultFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantOK bool
+	}{
+		{"dot", true},
+		{"mixed-content", true},
+		{"json", false},
+		{"pdf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := FindResultFormat(tt.name)
+			if ok != tt.wantOK || (ok && format.Name != tt.name) {
+				t.Errorf("FindResultFormat() = %v, %v, want %v", format.Name, ok, tt.wantOK)
+			}
+		})
+	}
+}
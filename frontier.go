@@ -0,0 +1,425 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ariefrahmansyah/href"
+	"github.com/prometheus/common/log"
+)
+
+// CrawlStrategy decides the order in which pages are crawled.
+type CrawlStrategy int
+
+const (
+	// DepthFirst follows every link recursively as soon as it is found.
+	DepthFirst CrawlStrategy = iota
+	// BreadthFirst crawls level by level from a shared frontier, so every URL
+	// is fetched once, at its shallowest depth.
+	BreadthFirst
+)
+
+// CrawlResult is the outcome of a breadth-first crawl.
+// StoppedBy names the limit that ended the crawl and Unvisited lists the
+// frontier URLs left unfetched because of limits, in crawl order. Trapped
+// lists the links that were not followed because they look like traps.
+// Broken lists the pages that could not be fetched.
+type CrawlResult struct {
+	Site      Site         `json:"site"`
+	Pages     int          `json:"pages"`
+	StoppedBy StopReason   `json:"stopped_by,omitempty"`
+	Unvisited []string     `json:"unvisited,omitempty"`
+	Trapped   []TrappedURL `json:"trapped,omitempty"`
+	Broken    []BrokenLink `json:"broken,omitempty"`
+}
+
+// BrokenLink is a page that could not be fetched and the crawled pages that
+// link to it. StatusCode is 0 when there was no response.
+type BrokenLink struct {
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Error      string   `json:"error"`
+	Sources    []string `json:"sources,omitempty"`
+}
+
+// frontierEntry is a URL waiting to be fetched.
+type frontierEntry struct {
+	Link   href.Link `json:"link"`
+	Depth  int       `json:"depth"`
+	Parent string    `json:"parent,omitempty"`
+}
+
+// crawledPage is a fetched URL and the place it was first found. Node is its
+// site, without sites, and Links are the links on it ordered by URL.
+type crawledPage struct {
+	Parent  string      `json:"parent,omitempty"`
+	Webpage bool        `json:"webpage"`
+	Node    Site        `json:"node"`
+	Links   []href.Link `json:"links,omitempty"`
+}
+
+// crawlState is everything a breadth-first crawl needs to go on. It is what a
+// checkpoint holds.
+type crawlState struct {
+	Query    CrawlQuery              `json:"query"`
+	Root     href.Link               `json:"root"`
+	Frontier []frontierEntry         `json:"frontier"`
+	Visited  map[string]bool         `json:"visited"`
+	Failed   map[string]bool         `json:"failed"`
+	Crawled  map[string]*crawledPage `json:"crawled"`
+	Bytes    int64                   `json:"bytes"`
+	Elapsed  time.Duration           `json:"elapsed"`
+	Result   CrawlResult             `json:"result"`
+}
+
+// CrawlBreadthFirst crawls query.Site level by level. Every URL is fetched at
+// most once, at the shallowest depth it is reachable from, and it is expanded
+// in the returned tree under the first parent that linked to it. Other parents
+// keep it as a leaf. Sites are ordered deterministically.
+func (crawler *Crawler) CrawlBreadthFirst(ctx context.Context, query CrawlQuery) (CrawlResult, error) {
+	if query.MaxDepth == 0 {
+		query.MaxDepth = defaultMaxDepth
+	}
+
+	if valid, err := crawler.Validate(ctx, query); !valid && err != nil {
+		return CrawlResult{}, fmt.Errorf("Query is not valid. { %v }", err)
+	}
+
+	siteURL, err := url.Parse(query.Site)
+	if err != nil {
+		return CrawlResult{}, fmt.Errorf("Failed to parse URL ( %s ). { %v }", query.Site, err)
+	}
+
+	if _, err := newScope(query); err != nil {
+		return CrawlResult{}, fmt.Errorf("Query is not valid. { %v }", err)
+	}
+
+	root := href.NewLink(ctx, siteURL, "", siteURL.String(), 0)
+
+	state := &crawlState{
+		Query:    query,
+		Root:     root,
+		Frontier: []frontierEntry{{Link: root, Depth: 0}},
+		Visited:  map[string]bool{root.URL.String(): true},
+		Failed:   make(map[string]bool),
+		Crawled:  make(map[string]*crawledPage),
+	}
+
+	return crawler.crawlFrom(ctx, state)
+}
+
+// crawlFrom goes on with a breadth-first crawl until the frontier is empty or
+// a limit stops it. When ctx is cancelled, the state is saved to the
+// checkpoint file and the crawl can be resumed.
+func (crawler *Crawler) crawlFrom(ctx context.Context, state *crawlState) (CrawlResult, error) {
+	query := state.Query
+	rootKey := state.Root.URL.String()
+
+	scope, err := newScope(query)
+	if err != nil {
+		return CrawlResult{}, fmt.Errorf("Query is not valid. { %v }", err)
+	}
+
+	interrupt := ctx
+	budget := newBudget(query)
+	budget.restore(state)
+
+	if query.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, budget.deadline)
+		defer cancel()
+	}
+
+	started, elapsed := time.Now(), state.Elapsed
+	lastCheckpoint := started
+
+	crawler.progress.update(func(p *Progress) {
+		*p = Progress{
+			Site:    rootKey,
+			Started: started.Add(-elapsed),
+			Pages:   len(state.Crawled),
+			Failed:  len(state.Failed),
+			Queued:  len(state.Frontier),
+			Bytes:   state.Bytes,
+		}
+	})
+
+	for len(state.Frontier) > 0 && state.Frontier[0].Depth < query.MaxDepth {
+		// The frontier is ordered by depth. It only holds two depths when an
+		// interrupted level is resumed.
+		level, rest := splitLevel(state.Frontier)
+		depth := level[0].Depth
+		log.Debugf("Crawling depth %d ( %d pages )", depth, len(level))
+
+		crawler.metrics.addQueueDepth(len(level))
+
+		// Results are handled in the order of the level, so the state always
+		// holds a prefix of it and can be saved between any two pages.
+		var retry, next []frontierEntry
+		crawler.crawlFrontier(ctx, level, budget, func(i int, fetched fetchResult) {
+			entry := level[i]
+			key := entry.Link.URL.String()
+
+			defer func() {
+				state.Bytes += fetched.page.bytes
+				if crawler.checkpointFile == "" || time.Since(lastCheckpoint) < crawler.checkpointInterval {
+					return
+				}
+
+				frontier := append(append([]frontierEntry{}, retry...), level[i+1:]...)
+				state.Frontier = append(append(frontier, rest...), next...)
+				state.Elapsed = elapsed + time.Since(started)
+				crawler.saveCheckpoint(state)
+				lastCheckpoint = time.Now()
+			}()
+
+			if interrupt.Err() != nil && (fetched.err != nil || fetched.skipped != "") {
+				retry = append(retry, entry)
+				return
+			}
+
+			if fetched.skipped != "" {
+				if state.Result.StoppedBy == "" || fetched.skipped.stopsCrawl() && !state.Result.StoppedBy.stopsCrawl() {
+					state.Result.StoppedBy = fetched.skipped
+				}
+				state.Result.Unvisited = append(state.Result.Unvisited, key)
+				return
+			}
+
+			if fetched.err != nil {
+				log.Errorf("Failed to crawl ( %s ). { %v }", key, fetched.err)
+				state.Failed[key] = true
+				state.Result.Broken = append(state.Result.Broken, BrokenLink{
+					URL:        key,
+					StatusCode: fetched.page.statusCode,
+					Error:      fetched.err.Error(),
+				})
+				return
+			}
+
+			p := fetched.page
+			node := Site{Data: entry.Link}
+			p.annotate(&node)
+
+			links := sortedLinks(p.links)
+			state.Crawled[key] = &crawledPage{Parent: entry.Parent, Webpage: p.webpage, Node: node, Links: links}
+
+			for _, link := range links {
+				linkKey := link.URL.String()
+				if state.Visited[linkKey] {
+					continue
+				}
+				state.Visited[linkKey] = true
+
+				if !scope.allows(link.URL) {
+					continue
+				}
+
+				if depth+1 < query.MaxDepth {
+					if reason := crawler.traps.check(link.URL); reason != "" {
+						log.Infof("Looks like a crawler trap ( %s ). Do not crawl ( %s )", reason, link.URL)
+						state.Result.Trapped = append(state.Result.Trapped, TrappedURL{URL: linkKey, Reason: reason})
+						continue
+					}
+				}
+
+				next = append(next, frontierEntry{Link: link, Depth: depth + 1, Parent: key})
+			}
+		})
+
+		state.Frontier = append(append(retry, rest...), next...)
+		state.Elapsed = elapsed + time.Since(started)
+
+		crawler.progress.update(func(p *Progress) {
+			p.Queued = len(state.Frontier)
+		})
+
+		if interrupt.Err() != nil {
+			crawler.saveCheckpoint(state)
+			return CrawlResult{}, fmt.Errorf("Crawl is interrupted ( %s ). { %v }", rootKey, interrupt.Err())
+		}
+
+		if state.Result.StoppedBy.stopsCrawl() {
+			log.Infof("Crawl stopped by %s ( %s )", state.Result.StoppedBy, rootKey)
+			for _, entry := range state.Frontier {
+				if entry.Depth < query.MaxDepth {
+					state.Result.Unvisited = append(state.Result.Unvisited, entry.Link.URL.String())
+				}
+			}
+			state.Frontier = nil
+			break
+		}
+	}
+
+	crawler.removeCheckpoint()
+
+	if state.Failed[rootKey] {
+		return CrawlResult{}, fmt.Errorf("Failed to crawl ( %s )", rootKey)
+	}
+
+	result := state.Result
+	result.Site = buildSite(rootKey, state.Root, state.Crawled, state.Failed)
+	result.Pages = len(state.Crawled)
+	result.Broken = brokenSources(result.Broken, state.Crawled)
+
+	return result, nil
+}
+
+// brokenSources sorts broken by URL and lists the crawled pages linking to
+// each of them.
+func brokenSources(broken []BrokenLink, crawled map[string]*crawledPage) []BrokenLink {
+	index := make(map[string]int, len(broken))
+	for i, link := range broken {
+		index[link.URL] = i
+		broken[i].Sources = nil
+	}
+
+	for key, p := range crawled {
+		for _, link := range p.Links {
+			if i, ok := index[link.URL.String()]; ok {
+				broken[i].Sources = append(broken[i].Sources, key)
+			}
+		}
+	}
+
+	for i := range broken {
+		sort.Strings(broken[i].Sources)
+	}
+	sort.Slice(broken, func(i, j int) bool { return broken[i].URL < broken[j].URL })
+
+	return broken
+}
+
+// splitLevel splits the entries of the shallowest depth off the frontier.
+func splitLevel(frontier []frontierEntry) (level, rest []frontierEntry) {
+	i := 0
+	for i < len(frontier) && frontier[i].Depth == frontier[0].Depth {
+		i++
+	}
+
+	return frontier[:i], frontier[i:]
+}
+
+// fetchResult is the outcome of a frontier entry. Skipped is set when a limit
+// kept the entry from being fetched.
+type fetchResult struct {
+	page    page
+	err     error
+	skipped StopReason
+}
+
+// crawlFrontier visits every entry allowed by budget using at most
+// crawler.concurrency workers. It calls handle with the result of every entry,
+// in the order of entries, as soon as the result and those before it are in.
+func (crawler *Crawler) crawlFrontier(ctx context.Context, entries []frontierEntry, budget *budget, handle func(i int, result fetchResult)) {
+	results := make([]fetchResult, len(entries))
+
+	indexes := make(chan int)
+	finished := make(chan int)
+
+	for w := 0; w < crawler.concurrency; w++ {
+		go func() {
+			for i := range indexes {
+				p, err := crawler.fetchPage(ctx, entries[i].Link.URL, entries[i].Depth)
+				if err != nil && budget.expired() {
+					results[i].skipped = LimitDuration
+					finished <- i
+					continue
+				}
+				budget.spend(p.bytes)
+
+				crawler.progress.update(func(progress *Progress) {
+					if err != nil {
+						progress.Failed++
+						return
+					}
+					progress.Pages++
+					progress.Bytes += p.bytes
+				})
+
+				results[i] = fetchResult{page: p, err: err}
+				finished <- i
+			}
+		}()
+	}
+
+	go func() {
+		for i, entry := range entries {
+			crawler.metrics.addQueueDepth(-1)
+			crawler.progress.update(func(p *Progress) { p.Queued-- })
+
+			if reason := budget.acquire(entry.Link.URL); reason != "" {
+				results[i].skipped = reason
+				finished <- i
+				continue
+			}
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	done := make([]bool, len(entries))
+	next := 0
+	for range entries {
+		done[<-finished] = true
+		for next < len(entries) && done[next] {
+			handle(next, results[next])
+			next++
+		}
+	}
+}
+
+// buildSite expands key and every page first discovered from it.
+func buildSite(key string, link href.Link, crawled map[string]*crawledPage, failed map[string]bool) Site {
+	p, ok := crawled[key]
+	if !ok {
+		return Site{Data: link}
+	}
+
+	if !p.Webpage {
+		site := p.Node
+		site.Data = link
+		return site
+	}
+
+	site := p.Node
+	site.mutex = &sync.Mutex{}
+	site.Data = link
+
+	for _, child := range p.Links {
+		childKey := child.URL.String()
+		if failed[childKey] {
+			continue
+		}
+
+		if c, ok := crawled[childKey]; ok && c.Parent == key {
+			site.Sites = append(site.Sites, buildSite(childKey, child, crawled, failed))
+		} else {
+			site.Sites = append(site.Sites, Site{Data: child})
+		}
+	}
+
+	sort.Stable(SitesSorter(site.Sites))
+
+	return site
+}
+
+// sortedLinks returns links ordered by URL.
+func sortedLinks(links map[string]href.Link) []href.Link {
+	keys := make([]string, 0, len(links))
+	for key := range links {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]href.Link, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, links[key])
+	}
+
+	return sorted
+}
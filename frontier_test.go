@@ -0,0 +1,351 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestCrawler_CrawlBreadthFirst(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		query CrawlQuery
+	}
+	tests := []struct {
+		name     string
+		crawler  *Crawler
+		args     args
+		want     CrawlResult
+		wantHits map[string]int
+		wantErr  bool
+	}{
+		{
+			"invalid query's site",
+			NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}),
+			args{
+				context.Background(),
+				CrawlQuery{
+					Site: "invalid site",
+				},
+			},
+			CrawlResult{},
+			map[string]int{},
+			true,
+		},
+		{
+			"each page once at its shallowest depth",
+			NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}),
+			args{
+				context.Background(),
+				CrawlQuery{
+					Site:     bfs0.URL,
+					MaxDepth: 3,
+				},
+			},
+			CrawlResult{
+				Site: Site{
+					mutex:       &sync.Mutex{},
+					Data:        href.NewLink(context.Background(), bfs0URL, "", bfs0URL.String(), 0),
+					StatusCode:  200,
+					ContentType: "text/html",
+					Metadata:    &Metadata{WordCount: 2},
+					Sites: []Site{
+						Site{
+							mutex:       &sync.Mutex{},
+							Data:        href.NewLink(context.Background(), bfs0URL, "1", bfs1URL.String(), 1),
+							StatusCode:  200,
+							ContentType: "text/html",
+							Metadata:    &Metadata{WordCount: 2},
+							Sites: []Site{
+								Site{
+									Data: href.NewLink(context.Background(), bfs1URL, "0", bfs0URL.String(), 2),
+								},
+								Site{
+									Data: href.NewLink(context.Background(), bfs1URL, "2", bfs2URL.String(), 2),
+								},
+							},
+						},
+						Site{
+							mutex:       &sync.Mutex{},
+							Data:        href.NewLink(context.Background(), bfs0URL, "2", bfs2URL.String(), 1),
+							StatusCode:  200,
+							ContentType: "text/html",
+							Metadata:    &Metadata{WordCount: 2},
+						},
+					},
+				},
+				Pages: 3,
+			},
+			map[string]int{
+				bfs0URL.Host: 1,
+				bfs1URL.Host: 1,
+				bfs2URL.Host: 1,
+			},
+			false,
+		},
+		{
+			"stopped by max pages",
+			NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}),
+			args{
+				context.Background(),
+				CrawlQuery{
+					Site:     bfs0.URL,
+					MaxDepth: 3,
+					MaxPages: 1,
+				},
+			},
+			CrawlResult{
+				Site: Site{
+					mutex:       &sync.Mutex{},
+					Data:        href.NewLink(context.Background(), bfs0URL, "", bfs0URL.String(), 0),
+					StatusCode:  200,
+					ContentType: "text/html",
+					Metadata:    &Metadata{WordCount: 2},
+					Sites: []Site{
+						Site{
+							Data: href.NewLink(context.Background(), bfs0URL, "1", bfs1URL.String(), 1),
+						},
+						Site{
+							Data: href.NewLink(context.Background(), bfs0URL, "2", bfs2URL.String(), 1),
+						},
+					},
+				},
+				Pages:     1,
+				StoppedBy: LimitPages,
+				Unvisited: sortedStrings(bfs1URL.String(), bfs2URL.String()),
+			},
+			map[string]int{
+				bfs0URL.Host: 1,
+			},
+			false,
+		},
+		{
+			"excluded links are not followed",
+			NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}),
+			args{
+				context.Background(),
+				CrawlQuery{
+					Site:     bfs0.URL,
+					MaxDepth: 3,
+					Exclude:  []string{"^" + regexp.QuoteMeta(bfs1.URL) + "$"},
+				},
+			},
+			CrawlResult{
+				Site: Site{
+					mutex:       &sync.Mutex{},
+					Data:        href.NewLink(context.Background(), bfs0URL, "", bfs0URL.String(), 0),
+					StatusCode:  200,
+					ContentType: "text/html",
+					Metadata:    &Metadata{WordCount: 2},
+					Sites: []Site{
+						Site{
+							Data: href.NewLink(context.Background(), bfs0URL, "1", bfs1URL.String(), 1),
+						},
+						Site{
+							mutex:       &sync.Mutex{},
+							Data:        href.NewLink(context.Background(), bfs0URL, "2", bfs2URL.String(), 1),
+							StatusCode:  200,
+							ContentType: "text/html",
+							Metadata:    &Metadata{WordCount: 2},
+						},
+					},
+				},
+				Pages: 2,
+			},
+			map[string]int{
+				bfs0URL.Host: 1,
+				bfs2URL.Host: 1,
+			},
+			false,
+		},
+		{
+			"invalid include pattern",
+			NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst}),
+			args{
+				context.Background(),
+				CrawlQuery{
+					Site:    bfs0.URL,
+					Include: []string{"("},
+				},
+			},
+			CrawlResult{},
+			map[string]int{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bfsHitsMutex.Lock()
+			bfsHits = make(map[string]int)
+			bfsHitsMutex.Unlock()
+
+			got, err := tt.crawler.CrawlBreadthFirst(tt.args.ctx, tt.args.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Crawler.CrawlBreadthFirst() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Site = withoutResponseTimes(got.Site); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Crawler.CrawlBreadthFirst() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(bfsHits, tt.wantHits) {
+				t.Errorf("hits = %v, want %v", bfsHits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestCrawler_CrawlBreadthFirst_broken(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	home := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="` + missing.URL + `">missing</a></body></html>`))
+	}))
+	defer home.Close()
+
+	crawler := NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst})
+	got, err := crawler.CrawlBreadthFirst(context.Background(), CrawlQuery{Site: home.URL})
+	if err != nil {
+		t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+	}
+
+	if len(got.Broken) != 1 {
+		t.Fatalf("Crawler.CrawlBreadthFirst() broken = %v, want 1 broken link", got.Broken)
+	}
+	broken := got.Broken[0]
+	if broken.URL != missing.URL || broken.StatusCode != http.StatusNotFound || !reflect.DeepEqual(broken.Sources, []string{home.URL}) {
+		t.Errorf("Crawler.CrawlBreadthFirst() broken = %+v, want %s linked from %s with status 404", broken, missing.URL, home.URL)
+	}
+
+	progress := crawler.Progress()
+	if progress.Site != home.URL || progress.Pages != 1 || progress.Failed != 1 || progress.Queued != 0 {
+		t.Errorf("Crawler.Progress() = %+v, want 1 page and 1 failed on %s", progress, home.URL)
+	}
+}
+
+func TestCrawler_CrawlBreadthFirst_cache(t *testing.T) {
+	cache := NewMemoryCache(10)
+	crawl := func() CrawlResult {
+		result, err := NewCrawler(context.Background(), CrawlerOpt{Strategy: BreadthFirst, Cache: cache}).CrawlBreadthFirst(context.Background(), CrawlQuery{Site: bfs0.URL, MaxDepth: 3})
+		if err != nil {
+			t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+		}
+		result.Site = withoutResponseTimes(result.Site)
+		return result
+	}
+
+	bfsHitsMutex.Lock()
+	bfsHits = make(map[string]int)
+	bfsHitsMutex.Unlock()
+
+	first := crawl()
+	want := make(map[string]int)
+	for host, hits := range bfsHits {
+		want[host] = hits
+	}
+	second := crawl()
+
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("Crawler.CrawlBreadthFirst() from cache = %v, want %v", second, first)
+	}
+	if !reflect.DeepEqual(bfsHits, want) {
+		t.Errorf("hits after crawling again = %v, want %v", bfsHits, want)
+	}
+}
+
+func sortedStrings(s ...string) []string {
+	sort.Strings(s)
+	return s
+}
+
+func Test_sortedLinks(t *testing.T) {
+	type args struct {
+		links map[string]href.Link
+	}
+	tests := []struct {
+		name string
+		args args
+		want []href.Link
+	}{
+		{
+			"empty",
+			args{
+				map[string]href.Link{},
+			},
+			[]href.Link{},
+		},
+		{
+			"ordered by URL",
+			args{
+				map[string]href.Link{
+					"https://monzo.com/2": href.NewLink(context.Background(), parentURL, "a", "/2", 1),
+					"https://monzo.com/1": href.NewLink(context.Background(), parentURL, "b", "/1", 1),
+				},
+			},
+			[]href.Link{
+				href.NewLink(context.Background(), parentURL, "b", "/1", 1),
+				href.NewLink(context.Background(), parentURL, "a", "/2", 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortedLinks(tt.args.links); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortedLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawler_Crawl_cacheOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Monzo</title></head><body><img src="/logo.png"><p>Banking made easy</p></body></html>`))
+	}))
+	defer server.Close()
+
+	crawls := []struct {
+		name  string
+		crawl func(crawler *Crawler) (Site, error)
+	}{
+		{"depth first", func(crawler *Crawler) (Site, error) {
+			return crawler.Crawl(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: 1}, 0)
+		}},
+		{"breadth first", func(crawler *Crawler) (Site, error) {
+			result, err := crawler.CrawlBreadthFirst(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: 1})
+			return result.Site, err
+		}},
+	}
+	for _, tt := range crawls {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryCache(10)
+			if _, err := tt.crawl(NewCrawler(context.Background(), CrawlerOpt{Cache: cache})); err != nil {
+				t.Fatalf("first crawl error = %v", err)
+			}
+
+			index := NewIndex()
+			site, err := tt.crawl(NewCrawler(context.Background(), CrawlerOpt{Cache: cache, Accessibility: true, Fingerprints: true, Index: index}))
+			if err != nil {
+				t.Fatalf("second crawl error = %v", err)
+			}
+
+			if len(site.Accessibility) == 0 {
+				t.Errorf("second crawl accessibility = %v, want the issues of the page", site.Accessibility)
+			}
+			if site.Fingerprint == nil {
+				t.Errorf("second crawl fingerprint = nil, want the fingerprint of the page")
+			}
+			if index.Len() != 1 {
+				t.Errorf("second crawl indexed %d pages, want 1", index.Len())
+			}
+		})
+	}
+}
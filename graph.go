@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+// GraphLink is an outbound link of a page.
+type GraphLink struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// Page is a page of a Graph. Site is the node the page was fetched at,
+// without its sites, or the first node it appears at when it wasn't fetched.
+// Error is set for a page that could not be fetched.
+type Page struct {
+	URL   string      `json:"url"`
+	Depth int         `json:"depth"`
+	Site  Site        `json:"site"`
+	Links []GraphLink `json:"links,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Fetched tells whether the page was fetched, as opposed to only linked to.
+func (p *Page) Fetched() bool {
+	return p.Site.StatusCode != 0
+}
+
+// Graph is the link structure of a crawl. A page appears once however many
+// times it is in the site tree.
+type Graph struct {
+	Root  string           `json:"root"`
+	Pages map[string]*Page `json:"pages"`
+}
+
+// NewGraph builds the graph of a site tree. The depth of a page is the
+// shallowest it appears at in the tree.
+func NewGraph(site Site) *Graph {
+	graph := &Graph{
+		Pages: make(map[string]*Page),
+	}
+	if site.Data.URL == nil {
+		return graph
+	}
+	graph.Root = site.Data.URL.String()
+
+	var walk func(site Site, depth int)
+	walk = func(site Site, depth int) {
+		if site.Data.URL == nil {
+			return
+		}
+		key := site.Data.URL.String()
+
+		node := site
+		node.Sites = nil
+
+		page, ok := graph.Pages[key]
+		if !ok {
+			page = &Page{URL: key, Depth: depth, Site: node}
+			graph.Pages[key] = page
+		}
+
+		if depth < page.Depth {
+			page.Depth = depth
+		}
+
+		if site.StatusCode != 0 && !page.Fetched() {
+			page.Site = node
+		}
+
+		if len(site.Sites) > 0 && len(page.Links) == 0 {
+			for _, s := range site.Sites {
+				if s.Data.URL != nil {
+					page.Links = append(page.Links, GraphLink{URL: s.Data.URL.String(), Text: s.Data.Text})
+				}
+			}
+		}
+
+		for _, s := range site.Sites {
+			walk(s, depth+1)
+		}
+	}
+	walk(site, 0)
+
+	return graph
+}
+
+// NewResultGraph builds the graph of a crawl result, adding its broken links,
+// which are left out of the site tree, as pages linked from their sources.
+func NewResultGraph(result CrawlResult) *Graph {
+	graph := NewGraph(result.Site)
+
+	for _, broken := range result.Broken {
+		brokenURL, err := url.Parse(broken.URL)
+		if err != nil {
+			continue
+		}
+
+		page, ok := graph.Pages[broken.URL]
+		if !ok {
+			page = &Page{URL: broken.URL, Depth: -1}
+			graph.Pages[broken.URL] = page
+		}
+		page.Site = Site{Data: href.Link{URL: brokenURL}, StatusCode: broken.StatusCode}
+		page.Error = broken.Error
+
+		for _, source := range broken.Sources {
+			sourcePage, ok := graph.Pages[source]
+			if !ok {
+				continue
+			}
+			sourcePage.Links = append(sourcePage.Links, GraphLink{URL: broken.URL})
+
+			if page.Depth < 0 || sourcePage.Depth+1 < page.Depth {
+				page.Depth = sourcePage.Depth + 1
+			}
+		}
+
+		if page.Depth < 0 {
+			page.Depth = 0
+		}
+	}
+
+	return graph
+}
+
+// URLs returns the URLs of every page, sorted.
+func (graph *Graph) URLs() []string {
+	urls := make([]string, 0, len(graph.Pages))
+	for u := range graph.Pages {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	return urls
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func TestNewGraph(t *testing.T) {
+	ctx := context.Background()
+	root := href.NewLink(ctx, parentURL, "", parentURL.String(), 0)
+	one := href.NewLink(ctx, parentURL, "1", "/1", 1)
+	two := href.NewLink(ctx, parentURL, "2", "/2", 1)
+	oneAgain := href.NewLink(ctx, parentURL, "one", "/1", 2)
+
+	tests := []struct {
+		name string
+		site Site
+		want *Graph
+	}{
+		{
+			"empty site",
+			Site{},
+			&Graph{
+				Pages: map[string]*Page{},
+			},
+		},
+		{
+			"page linked twice",
+			Site{
+				Data:       root,
+				StatusCode: 200,
+				Sites: []Site{
+					Site{Data: one},
+					Site{
+						Data:       two,
+						StatusCode: 200,
+						Sites: []Site{
+							Site{Data: oneAgain, StatusCode: 404},
+						},
+					},
+				},
+			},
+			&Graph{
+				Root: root.URL.String(),
+				Pages: map[string]*Page{
+					root.URL.String(): &Page{
+						URL:   root.URL.String(),
+						Depth: 0,
+						Site:  Site{Data: root, StatusCode: 200},
+						Links: []GraphLink{
+							{URL: one.URL.String(), Text: "1"},
+							{URL: two.URL.String(), Text: "2"},
+						},
+					},
+					one.URL.String(): &Page{
+						URL:   one.URL.String(),
+						Depth: 1,
+						Site:  Site{Data: oneAgain, StatusCode: 404},
+					},
+					two.URL.String(): &Page{
+						URL:   two.URL.String(),
+						Depth: 1,
+						Site:  Site{Data: two, StatusCode: 200},
+						Links: []GraphLink{
+							{URL: one.URL.String(), Text: "one"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewGraph(tt.site); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewGraph() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResultGraph(t *testing.T) {
+	graph := NewResultGraph(exportResult())
+
+	missing, ok := graph.Pages["https://monzo.com/missing"]
+	if !ok {
+		t.Fatalf("NewResultGraph() has no broken page")
+	}
+	if missing.Depth != 1 || missing.Site.StatusCode != 404 || missing.Error != "not found" {
+		t.Errorf("NewResultGraph() broken page = %+v, want depth 1, status 404 and its error", missing)
+	}
+
+	wantLinks := []GraphLink{
+		{URL: "https://monzo.com/blog/a", Text: "A post"},
+		{URL: "https://monzo.com/missing"},
+	}
+	if got := graph.Pages["https://monzo.com"].Links; !reflect.DeepEqual(got, wantLinks) {
+		t.Errorf("NewResultGraph() root links = %v, want %v", got, wantLinks)
+	}
+}
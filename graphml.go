@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+var graphMLKeys = []graphMLKey{
+	{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+	{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"},
+	{ID: "status", For: "node", AttrName: "status", AttrType: "int"},
+	{ID: "inbound", For: "node", AttrName: "inbound", AttrType: "int"},
+	{ID: "pagerank", For: "node", AttrName: "pagerank", AttrType: "double"},
+	{ID: "error", For: "node", AttrName: "error", AttrType: "string"},
+	{ID: "text", For: "edge", AttrName: "text", AttrType: "string"},
+	{ID: "target_depth", For: "edge", AttrName: "target_depth", AttrType: "int"},
+	{ID: "target_status", For: "edge", AttrName: "target_status", AttrType: "int"},
+}
+
+// WriteGraphML writes the graph as GraphML. Nodes carry the URL as label, the
+// depth, the status, the inbound links, the PageRank and the error of every
+// page, and edges the anchor text with the depth and status of their target.
+func (graph *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "crawl", EdgeDefault: "directed"},
+	}
+
+	stats := graph.Analyze()
+	urls := graph.URLs()
+	ids := make(map[string]string, len(urls))
+	for i, u := range urls {
+		ids[u] = fmt.Sprintf("n%d", i)
+	}
+
+	for _, u := range urls {
+		page := graph.Pages[u]
+
+		node := graphMLNode{
+			ID: ids[u],
+			Data: []graphMLData{
+				{Key: "label", Value: u},
+				{Key: "depth", Value: strconv.Itoa(page.Depth)},
+				{Key: "status", Value: strconv.Itoa(page.Site.StatusCode)},
+				{Key: "inbound", Value: strconv.Itoa(stats.Pages[u].Inbound)},
+				{Key: "pagerank", Value: strconv.FormatFloat(stats.Pages[u].PageRank, 'f', 6, 64)},
+			},
+		}
+		if page.Error != "" {
+			node.Data = append(node.Data, graphMLData{Key: "error", Value: page.Error})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+
+		for _, link := range page.Links {
+			target, ok := graph.Pages[link.URL]
+			if !ok {
+				continue
+			}
+
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				ID:     fmt.Sprintf("e%d", len(doc.Graph.Edges)),
+				Source: ids[u],
+				Target: ids[link.URL],
+				Data: []graphMLData{
+					{Key: "text", Value: link.Text},
+					{Key: "target_depth", Value: strconv.Itoa(target.Depth)},
+					{Key: "target_status", Value: strconv.Itoa(target.Site.StatusCode)},
+				},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("Failed to encode GraphML. { %v }", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
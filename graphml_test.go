@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestGraph_WriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewResultGraph(exportResult()).WriteGraphML(&buf); err != nil {
+		t.Fatalf("Graph.WriteGraphML() error = %v", err)
+	}
+
+	var doc graphMLDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Graph.WriteGraphML() wrote invalid XML. { %v }", err)
+	}
+
+	wantNodes := []graphMLNode{
+		{ID: "n0", Data: []graphMLData{{"label", "https://monzo.com"}, {"depth", "0"}, {"status", "200"}, {"inbound", "0"}, {"pagerank", "0.259740"}}},
+		{ID: "n1", Data: []graphMLData{{"label", "https://monzo.com/blog/a"}, {"depth", "1"}, {"status", "200"}, {"inbound", "1"}, {"pagerank", "0.370130"}}},
+		{ID: "n2", Data: []graphMLData{{"label", "https://monzo.com/missing"}, {"depth", "1"}, {"status", "404"}, {"inbound", "1"}, {"pagerank", "0.370130"}, {"error", "not found"}}},
+	}
+	if !reflect.DeepEqual(doc.Graph.Nodes, wantNodes) {
+		t.Errorf("Graph.WriteGraphML() nodes = %v, want %v", doc.Graph.Nodes, wantNodes)
+	}
+
+	wantEdges := []graphMLEdge{
+		{ID: "e0", Source: "n0", Target: "n1", Data: []graphMLData{{"text", "A post"}, {"target_depth", "1"}, {"target_status", "200"}}},
+		{ID: "e1", Source: "n0", Target: "n2", Data: []graphMLData{{"text", ""}, {"target_depth", "1"}, {"target_status", "404"}}},
+	}
+	if !reflect.DeepEqual(doc.Graph.Edges, wantEdges) {
+		t.Errorf("Graph.WriteGraphML() edges = %v, want %v", doc.Graph.Edges, wantEdges)
+	}
+
+	if len(doc.Keys) != len(graphMLKeys) {
+		t.Errorf("Graph.WriteGraphML() keys = %v, want %v", doc.Keys, graphMLKeys)
+	}
+}
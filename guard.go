@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// defaultBlockedCIDRs are the loopback, link-local, private and otherwise
+// internal ranges blocked by every AddressGuard.
+var defaultBlockedCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// AddressGuard keeps the crawler from connecting to internal addresses. It
+// checks the address being dialed, after DNS resolution, so every redirect is
+// covered too.
+type AddressGuard struct {
+	blocked []*net.IPNet
+}
+
+// NewAddressGuard blocks the default internal ranges plus the given CIDRs.
+func NewAddressGuard(cidrs ...string) (*AddressGuard, error) {
+	guard := &AddressGuard{}
+
+	for _, cidr := range append(defaultBlockedCIDRs, cidrs...) {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse CIDR ( %s ). { %v }", cidr, err)
+		}
+		guard.blocked = append(guard.blocked, ipNet)
+	}
+
+	return guard, nil
+}
+
+// Allowed tells whether ip is outside every blocked range.
+func (guard *AddressGuard) Allowed(ip net.IP) bool {
+	for _, ipNet := range guard.blocked {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Transport returns an HTTP transport that refuses to connect to blocked
+// addresses. It doesn't use a proxy, as that would hide the real address.
+func (guard *AddressGuard) Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   guard.control,
+	}
+
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// control is called by the dialer with the resolved address right before
+// connecting.
+func (guard *AddressGuard) control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("Failed to parse address ( %s ). { %v }", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !guard.Allowed(ip) {
+		return fmt.Errorf("Address is not allowed ( %s )", address)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestNewAddressGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		wantErr bool
+	}{
+		{"defaults", nil, false},
+		{"extra CIDR", []string{"203.0.113.0/24"}, false},
+		{"invalid CIDR", []string{"203.0.113.0"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAddressGuard(tt.cidrs...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewAddressGuard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddressGuard_Allowed(t *testing.T) {
+	guard, _ := NewAddressGuard("203.0.113.0/24")
+
+	tests := []struct {
+		name string
+		ip   string
+		want bool
+	}{
+		{"public", "8.8.8.8", true},
+		{"public v6", "2001:4860:4860::8888", true},
+		{"loopback", "127.0.0.1", false},
+		{"loopback v6", "::1", false},
+		{"metadata", "169.254.169.254", false},
+		{"private", "10.1.2.3", false},
+		{"private 172", "172.20.0.1", false},
+		{"private 192", "192.168.1.1", false},
+		{"unique local v6", "fd00::1", false},
+		{"mapped loopback", "::ffff:127.0.0.1", false},
+		{"unspecified", "0.0.0.0", false},
+		{"user configured", "203.0.113.7", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guard.Allowed(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("AddressGuard.Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressGuard_Transport(t *testing.T) {
+	guard, _ := NewAddressGuard()
+
+	tests := []struct {
+		name    string
+		site    string
+		wantErr bool
+	}{
+		{"loopback", emptyPage.URL, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), CrawlerOpt{AddressGuard: guard})
+
+			siteURL, _ := url.Parse(tt.site)
+			_, err := crawler.Fetch(context.Background(), siteURL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Crawler.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
 )
 
@@ -39,6 +40,23 @@ var mock011URL *url.URL
 var mock012 *httptest.Server
 var mock012URL *url.URL
 
+// {
+// 	0:
+// 		1:
+// 			0,
+// 			2,
+// 		2,
+// }
+var bfs0 *httptest.Server
+var bfs0URL *url.URL
+var bfs1 *httptest.Server
+var bfs1URL *url.URL
+var bfs2 *httptest.Server
+var bfs2URL *url.URL
+
+var bfsHits map[string]int
+var bfsHitsMutex sync.Mutex
+
 func TestMain(m *testing.M) {
 	os.Exit(testMain(m))
 }
@@ -104,5 +122,42 @@ func testMain(m *testing.M) int {
 	defer mock0.Close()
 	mock0URL, _ = url.Parse(mock0.URL)
 
+	bfsHits = make(map[string]int)
+	countHit := func(w http.ResponseWriter, r *http.Request) {
+		bfsHitsMutex.Lock()
+		defer bfsHitsMutex.Unlock()
+		bfsHits[r.Host]++
+		w.Header().Set("Content-Type", "text/html")
+	}
+
+	bfs2 = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		countHit(w, r)
+		w.Write([]byte(`<html><body>Last page</body></html>`))
+	}))
+	defer bfs2.Close()
+	bfs2URL, _ = url.Parse(bfs2.URL)
+
+	bfs1 = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		countHit(w, r)
+		w.Write([]byte(`
+			<html><body>
+				<a href="` + bfs0.URL + `">0</a>
+				<a href="` + bfs2.URL + `">2</a>
+			</body></html>`))
+	}))
+	defer bfs1.Close()
+	bfs1URL, _ = url.Parse(bfs1.URL)
+
+	bfs0 = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		countHit(w, r)
+		w.Write([]byte(`
+			<html><body>
+				<a href="` + bfs1.URL + `">1</a>
+				<a href="` + bfs2.URL + `">2</a>
+			</body></html>`))
+	}))
+	defer bfs0.Close()
+	bfs0URL, _ = url.Parse(bfs0.URL)
+
 	return m.Run()
 }
@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// StopReason names the crawl limit that kept pages from being fetched.
+type StopReason string
+
+const (
+	LimitPages        StopReason = "max_pages"
+	LimitBytes        StopReason = "max_bytes"
+	LimitPagesPerHost StopReason = "max_pages_per_host"
+	LimitDuration     StopReason = "max_duration"
+)
+
+// stopsCrawl tells whether reaching the limit ends the whole crawl. The per
+// host limit only skips pages on hosts that have used their share.
+func (reason StopReason) stopsCrawl() bool {
+	return reason != "" && reason != LimitPagesPerHost
+}
+
+func (query CrawlQuery) hasLimits() bool {
+	return query.MaxPages > 0 || query.MaxBytes > 0 || query.MaxPagesPerHost > 0 || query.MaxDuration > 0
+}
+
+// budget tracks the limits of a query while pages are fetched concurrently.
+type budget struct {
+	mutex    sync.Mutex
+	query    CrawlQuery
+	deadline time.Time
+	pages    int
+	bytes    int64
+	hosts    map[string]int
+}
+
+func newBudget(query CrawlQuery) *budget {
+	b := &budget{
+		query: query,
+		hosts: make(map[string]int),
+	}
+
+	if query.MaxDuration > 0 {
+		b.deadline = time.Now().Add(query.MaxDuration)
+	}
+
+	return b
+}
+
+// acquire reserves a fetch of siteURL. It returns the limit that forbids it,
+// or an empty StopReason when the page may be fetched.
+func (b *budget) acquire(siteURL *url.URL) StopReason {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.expired() {
+		return LimitDuration
+	}
+
+	if b.query.MaxPages > 0 && b.pages >= b.query.MaxPages {
+		return LimitPages
+	}
+
+	if b.query.MaxBytes > 0 && b.bytes >= b.query.MaxBytes {
+		return LimitBytes
+	}
+
+	if b.query.MaxPagesPerHost > 0 && b.hosts[siteURL.Host] >= b.query.MaxPagesPerHost {
+		return LimitPagesPerHost
+	}
+
+	b.pages++
+	b.hosts[siteURL.Host]++
+
+	return ""
+}
+
+// spend records bytes downloaded.
+func (b *budget) spend(bytes int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.bytes += bytes
+}
+
+// spent returns the bytes downloaded so far.
+func (b *budget) spent() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.bytes
+}
+
+// restore accounts for the pages, bytes and time a resumed crawl already
+// used.
+func (b *budget) restore(state *crawlState) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	count := func(key string) {
+		b.pages++
+		if u, err := url.Parse(key); err == nil {
+			b.hosts[u.Host]++
+		}
+	}
+	for key := range state.Crawled {
+		count(key)
+	}
+	for key := range state.Failed {
+		count(key)
+	}
+
+	b.bytes = state.Bytes
+
+	if b.query.MaxDuration > 0 {
+		b.deadline = time.Now().Add(b.query.MaxDuration - state.Elapsed)
+	}
+}
+
+func (b *budget) expired() bool {
+	return !b.deadline.IsZero() && time.Now().After(b.deadline)
+}
+
+// countingReadCloser counts the bytes read through it.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
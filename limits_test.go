@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStopReason_stopsCrawl(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason StopReason
+		want   bool
+	}{
+		{"none", "", false},
+		{"max pages", LimitPages, true},
+		{"max bytes", LimitBytes, true},
+		{"max duration", LimitDuration, true},
+		{"max pages per host", LimitPagesPerHost, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reason.stopsCrawl(); got != tt.want {
+				t.Errorf("StopReason.stopsCrawl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_budget_acquire(t *testing.T) {
+	otherHost, _ := url.Parse("https://mondo.com")
+
+	type fields struct {
+		query    CrawlQuery
+		deadline time.Time
+		pages    int
+		bytes    int64
+		hosts    map[string]int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		siteURL *url.URL
+		want    StopReason
+	}{
+		{
+			"no limits",
+			fields{CrawlQuery{}, time.Time{}, 100, 1 << 20, map[string]int{}},
+			parentURL,
+			"",
+		},
+		{
+			"max pages",
+			fields{CrawlQuery{MaxPages: 2}, time.Time{}, 2, 0, map[string]int{}},
+			parentURL,
+			LimitPages,
+		},
+		{
+			"max bytes",
+			fields{CrawlQuery{MaxBytes: 10}, time.Time{}, 1, 10, map[string]int{}},
+			parentURL,
+			LimitBytes,
+		},
+		{
+			"max pages per host",
+			fields{CrawlQuery{MaxPagesPerHost: 1}, time.Time{}, 1, 0, map[string]int{parentURL.Host: 1}},
+			parentURL,
+			LimitPagesPerHost,
+		},
+		{
+			"max pages per host, other host",
+			fields{CrawlQuery{MaxPagesPerHost: 1}, time.Time{}, 1, 0, map[string]int{parentURL.Host: 1}},
+			otherHost,
+			"",
+		},
+		{
+			"max duration",
+			fields{CrawlQuery{MaxDuration: time.Second}, time.Now().Add(-time.Second), 0, 0, map[string]int{}},
+			parentURL,
+			LimitDuration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBudget(tt.fields.query)
+			b.deadline = tt.fields.deadline
+			b.pages = tt.fields.pages
+			b.bytes = tt.fields.bytes
+			b.hosts = tt.fields.hosts
+
+			if got := b.acquire(tt.siteURL); got != tt.want {
+				t.Errorf("budget.acquire() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/yhat/scrape"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Metadata is what a page tells about itself: its title, description and
+// first level headings, the language of its html element, its canonical URL
+// resolved against the page URL, its robots meta tag, its Open Graph and
+// Twitter card properties keyed by name, like og:title, and the number of
+// words in its body text.
+type Metadata struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	H1          []string          `json:"h1,omitempty"`
+	Lang        string            `json:"lang,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Robots      string            `json:"robots,omitempty"`
+	OpenGraph   map[string]string `json:"open_graph,omitempty"`
+	Twitter     map[string]string `json:"twitter,omitempty"`
+	WordCount   int               `json:"word_count"`
+}
+
+// ExtractMetadata reads the metadata of the page at siteURL from its DOM.
+// The first title, description, canonical and robots tags win, as they do
+// for search engines.
+func ExtractMetadata(root *html.Node, siteURL *url.URL) *Metadata {
+	metadata := &Metadata{}
+
+	if htmlNode, ok := scrape.Find(root, scrape.ByTag(atom.Html)); ok {
+		metadata.Lang = strings.TrimSpace(scrape.Attr(htmlNode, "lang"))
+	}
+
+	if title, ok := scrape.Find(root, scrape.ByTag(atom.Title)); ok {
+		metadata.Title = scrape.Text(title)
+	}
+
+	for _, h1 := range scrape.FindAll(root, scrape.ByTag(atom.H1)) {
+		metadata.H1 = append(metadata.H1, scrape.Text(h1))
+	}
+
+	for _, meta := range scrape.FindAll(root, scrape.ByTag(atom.Meta)) {
+		name := strings.ToLower(scrape.Attr(meta, "name"))
+		if name == "" {
+			name = strings.ToLower(scrape.Attr(meta, "property"))
+		}
+		content := strings.TrimSpace(scrape.Attr(meta, "content"))
+
+		switch {
+		case name == "description" && metadata.Description == "":
+			metadata.Description = content
+		case name == "robots" && metadata.Robots == "":
+			metadata.Robots = content
+		case strings.HasPrefix(name, "og:"):
+			if metadata.OpenGraph == nil {
+				metadata.OpenGraph = make(map[string]string)
+			}
+			metadata.OpenGraph[name] = content
+		case strings.HasPrefix(name, "twitter:"):
+			if metadata.Twitter == nil {
+				metadata.Twitter = make(map[string]string)
+			}
+			metadata.Twitter[name] = content
+		}
+	}
+
+	for _, link := range scrape.FindAll(root, scrape.ByTag(atom.Link)) {
+		if !hasToken(scrape.Attr(link, "rel"), "canonical") {
+			continue
+		}
+
+		canonical, err := siteURL.Parse(strings.TrimSpace(scrape.Attr(link, "href")))
+		if err == nil {
+			metadata.Canonical = canonical.String()
+		}
+		break
+	}
+
+	if body, ok := scrape.Find(root, scrape.ByTag(atom.Body)); ok {
+		metadata.WordCount = len(textWords(body))
+	}
+
+	return metadata
+}
+
+// hasToken tells whether the space separated list s has token, ignoring case.
+func hasToken(s, token string) bool {
+	for _, t := range strings.Fields(s) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// textWords are the words of the text under node, leaving out scripts and
+// styles, which aren't read.
+func textWords(node *html.Node) []string {
+	switch {
+	case node.Type == html.TextNode:
+		return strings.Fields(node.Data)
+	case node.Type == html.ElementNode && (node.DataAtom == atom.Script || node.DataAtom == atom.Style || node.DataAtom == atom.Template || node.DataAtom == atom.Noscript):
+		return nil
+	}
+
+	var words []string
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		words = append(words, textWords(child)...)
+	}
+
+	return words
+}
+
+// normalizeWord lowercases word and trims the punctuation around it.
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
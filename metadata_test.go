@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *Metadata
+	}{
+		{
+			"empty page",
+			`<html><body></body></html>`,
+			&Metadata{},
+		},
+		{
+			"full head",
+			`<html lang="en-GB"><head>
+				<title> Monzo - Banking made easy </title>
+				<meta name="description" content="A bank that lives on your phone.">
+				<meta name="Description" content="Ignored, the first one wins.">
+				<meta name="robots" content="noindex, follow">
+				<meta property="og:title" content="Monzo">
+				<meta property="og:image" content="https://monzo.com/og.png">
+				<meta name="twitter:card" content="summary">
+				<link rel="Alternate Canonical" href="/home">
+			</head><body>
+				<h1>Banking made easy</h1>
+				<h1>Open an account</h1>
+				<script>var ignored = "not words";</script>
+				<style>p { color: red; }</style>
+				<p>It only takes a few minutes.</p>
+			</body></html>`,
+			&Metadata{
+				Title:       "Monzo - Banking made easy",
+				Description: "A bank that lives on your phone.",
+				H1:          []string{"Banking made easy", "Open an account"},
+				Lang:        "en-GB",
+				Canonical:   "https://monzo.com/home",
+				Robots:      "noindex, follow",
+				OpenGraph:   map[string]string{"og:title": "Monzo", "og:image": "https://monzo.com/og.png"},
+				Twitter:     map[string]string{"twitter:card": "summary"},
+				WordCount:   12,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ExtractMetadata(root, about); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawler_Crawl_metadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Home</title></head><body>Hello</body></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opt  CrawlerOpt
+		want *Metadata
+	}{
+		{"extracted by default", CrawlerOpt{}, &Metadata{Title: "Home", WordCount: 1}},
+		{"disabled", CrawlerOpt{DisableMetadata: true}, nil},
+		{"streaming links", CrawlerOpt{StreamLinks: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, err := NewCrawler(context.Background(), tt.opt).Crawl(context.Background(), CrawlQuery{Site: server.URL}, 0)
+			if err != nil {
+				t.Fatalf("Crawler.Crawl() error = %v", err)
+			}
+			if !reflect.DeepEqual(site.Metadata, tt.want) {
+				t.Errorf("Crawler.Crawl() metadata = %+v, want %+v", site.Metadata, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "crawler"
+
+// Metrics are the Prometheus collectors of a Crawler. Crawlers registered on
+// the same Registerer share their collectors.
+type Metrics struct {
+	PagesFetched    *prometheus.CounterVec
+	FetchDuration   *prometheus.HistogramVec
+	BytesDownloaded prometheus.Counter
+	QueueDepth      prometheus.Gauge
+	ActiveWorkers   prometheus.Gauge
+	CacheHits       prometheus.Counter
+	CacheMisses     prometheus.Counter
+}
+
+// NewMetrics creates the crawler collectors and registers them on registerer.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		PagesFetched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "pages_fetched_total",
+			Help:      "Pages fetched, by response status class.",
+		}, []string{"class"}),
+		FetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "fetch_duration_seconds",
+			Help:      "Time to fetch a page, by host for crawlers with HostMetrics.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"host"}),
+		BytesDownloaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "downloaded_bytes_total",
+			Help:      "Bytes of page bodies downloaded.",
+		}),
+		QueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "queue_depth",
+			Help:      "URLs in the frontier waiting to be fetched.",
+		}),
+		ActiveWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_workers",
+			Help:      "Fetches in flight.",
+		}),
+		CacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_hits_total",
+			Help:      "Sites found in the cache.",
+		}),
+		CacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_misses_total",
+			Help:      "Sites not found in the cache.",
+		}),
+	}
+
+	if registerer == nil {
+		return m, nil
+	}
+
+	for _, r := range []struct {
+		collector prometheus.Collector
+		existing  func(prometheus.Collector)
+	}{
+		{m.PagesFetched, func(c prometheus.Collector) { m.PagesFetched = c.(*prometheus.CounterVec) }},
+		{m.FetchDuration, func(c prometheus.Collector) { m.FetchDuration = c.(*prometheus.HistogramVec) }},
+		{m.BytesDownloaded, func(c prometheus.Collector) { m.BytesDownloaded = c.(prometheus.Counter) }},
+		{m.QueueDepth, func(c prometheus.Collector) { m.QueueDepth = c.(prometheus.Gauge) }},
+		{m.ActiveWorkers, func(c prometheus.Collector) { m.ActiveWorkers = c.(prometheus.Gauge) }},
+		{m.CacheHits, func(c prometheus.Collector) { m.CacheHits = c.(prometheus.Counter) }},
+		{m.CacheMisses, func(c prometheus.Collector) { m.CacheMisses = c.(prometheus.Counter) }},
+	} {
+		if err := registerer.Register(r.collector); err != nil {
+			are, ok := err.(prometheus.AlreadyRegisteredError)
+			if !ok {
+				return nil, fmt.Errorf("Failed to register metrics. { %v }", err)
+			}
+			// Share the collector of a crawler registered earlier.
+			r.existing(are.ExistingCollector)
+		}
+	}
+
+	return m, nil
+}
+
+// The methods below do nothing on a nil *Metrics, which is what a Crawler
+// without a Registerer has.
+
+func (m *Metrics) observeFetch(host string, resp *http.Response, err error, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+
+	class := "error"
+	if err == nil {
+		class = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+
+	m.PagesFetched.WithLabelValues(class).Inc()
+	m.FetchDuration.WithLabelValues(host).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) addBytes(n int64) {
+	if m == nil {
+		return
+	}
+	m.BytesDownloaded.Add(float64(n))
+}
+
+func (m *Metrics) addQueueDepth(n int) {
+	if m == nil {
+		return
+	}
+	m.QueueDepth.Add(float64(n))
+}
+
+func (m *Metrics) addActiveWorkers(n int) {
+	if m == nil {
+		return
+	}
+	m.ActiveWorkers.Add(float64(n))
+}
+
+func (m *Metrics) cacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.CacheHits.Inc()
+	} else {
+		m.CacheMisses.Inc()
+	}
+}
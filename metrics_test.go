@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	c.Write(m)
+	return m.GetCounter().GetValue()
+}
+
+func TestNewMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	first, err := NewMetrics(registry)
+	if err != nil {
+		t.Fatalf("NewMetrics() error = %v", err)
+	}
+
+	second, err := NewMetrics(registry)
+	if err != nil {
+		t.Fatalf("NewMetrics() error = %v", err)
+	}
+
+	if first.PagesFetched != second.PagesFetched || first.BytesDownloaded != second.BytesDownloaded {
+		t.Errorf("NewMetrics() on the same registerer doesn't share collectors")
+	}
+}
+
+func TestCrawler_metrics(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	tests := []struct {
+		name        string
+		site        string
+		hostMetrics bool
+		wantClass   string
+		wantBytes   float64
+		wantHost    string
+		wantErr     bool
+	}{
+		{"fetched", emptyPage.URL, false, "2xx", float64(len(`<html><body></body></html>`)), "", false},
+		{"unavailable", unavailable.URL, false, "5xx", 0, "", true},
+		{"host metrics", emptyPage.URL, true, "2xx", float64(len(`<html><body></body></html>`)), emptyPageURL.Host, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), CrawlerOpt{Registerer: prometheus.NewRegistry(), HostMetrics: tt.hostMetrics})
+
+			siteURL, _ := url.Parse(tt.site)
+			if _, err := crawler.visit(context.Background(), siteURL, 0); (err != nil) != tt.wantErr {
+				t.Fatalf("Crawler.visit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := counterValue(crawler.metrics.PagesFetched.WithLabelValues(tt.wantClass)); got != 1 {
+				t.Errorf("pages fetched with %s = %v, want 1", tt.wantClass, got)
+			}
+			if got := counterValue(crawler.metrics.BytesDownloaded); got != tt.wantBytes {
+				t.Errorf("bytes downloaded = %v, want %v", got, tt.wantBytes)
+			}
+
+			fetches := make(chan prometheus.Metric, 2)
+			crawler.metrics.FetchDuration.Collect(fetches)
+			close(fetches)
+
+			var hosts []string
+			for fetch := range fetches {
+				m := &dto.Metric{}
+				fetch.Write(m)
+				hosts = append(hosts, m.GetLabel()[0].GetValue())
+			}
+			if len(hosts) != 1 || hosts[0] != tt.wantHost {
+				t.Errorf("fetch duration hosts = %q, want [%q]", hosts, tt.wantHost)
+			}
+		})
+	}
+}
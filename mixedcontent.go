@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yhat/scrape"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ResourceKind is what a page does with a URL it references.
+type ResourceKind string
+
+const (
+	ResourceScript     ResourceKind = "script"
+	ResourceStylesheet ResourceKind = "stylesheet"
+	ResourceImage      ResourceKind = "image"
+	ResourceIframe     ResourceKind = "iframe"
+	ResourceForm       ResourceKind = "form"
+	ResourceLink       ResourceKind = "link"
+)
+
+// Active tells whether the resource can change the page, so browsers block it
+// when it is loaded over HTTP on an HTTPS page instead of only warning.
+func (kind ResourceKind) Active() bool {
+	return kind == ResourceScript || kind == ResourceStylesheet || kind == ResourceIframe
+}
+
+// Resource is a URL referenced by a page, resolved against the page URL.
+// Path locates the element referencing it, like html > body > img.
+type Resource struct {
+	Kind ResourceKind `json:"kind"`
+	URL  string       `json:"url"`
+	Path string       `json:"path"`
+}
+
+// ExtractResources finds the scripts, stylesheets, images, iframes, form
+// actions and links of the page at siteURL in its DOM. Unlike the links the
+// crawler follows, links to other domains and links without text are kept.
+// Only http and https URLs are returned, in document order.
+func ExtractResources(root *html.Node, siteURL *url.URL) []Resource {
+	var resources []Resource
+	add := func(kind ResourceKind, node *html.Node, ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+
+		u, err := siteURL.Parse(ref)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		resources = append(resources, Resource{Kind: kind, URL: u.String(), Path: domPath(node)})
+	}
+
+	elements := scrape.FindAllNested(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Script, atom.Link, atom.Img, atom.Iframe, atom.Form, atom.A:
+			return n.Type == html.ElementNode
+		}
+		return false
+	})
+
+	for _, node := range elements {
+		switch node.DataAtom {
+		case atom.Script:
+			add(ResourceScript, node, scrape.Attr(node, "src"))
+		case atom.Link:
+			if hasToken(scrape.Attr(node, "rel"), "stylesheet") {
+				add(ResourceStylesheet, node, scrape.Attr(node, "href"))
+			}
+		case atom.Img:
+			add(ResourceImage, node, scrape.Attr(node, "src"))
+			for _, candidate := range strings.Split(scrape.Attr(node, "srcset"), ",") {
+				if fields := strings.Fields(candidate); len(fields) > 0 {
+					add(ResourceImage, node, fields[0])
+				}
+			}
+		case atom.Iframe:
+			add(ResourceIframe, node, scrape.Attr(node, "src"))
+		case atom.Form:
+			add(ResourceForm, node, scrape.Attr(node, "action"))
+		case atom.A:
+			add(ResourceLink, node, scrape.Attr(node, "href"))
+		}
+	}
+
+	return resources
+}
+
+// FindMixedContent returns the resources of an HTTPS page that are loaded or
+// submitted over plain HTTP, and its links that downgrade to HTTP. It returns
+// nil for a page that isn't served over HTTPS.
+func FindMixedContent(root *html.Node, siteURL *url.URL) []Resource {
+	if siteURL.Scheme != "https" {
+		return nil
+	}
+
+	var insecure []Resource
+	for _, resource := range ExtractResources(root, siteURL) {
+		if strings.HasPrefix(resource.URL, "http:") {
+			insecure = append(insecure, resource)
+		}
+	}
+
+	return insecure
+}
+
+// MixedContent maps the URL of every page of the graph with mixed content to
+// its HTTP resources and links.
+func (graph *Graph) MixedContent() map[string][]Resource {
+	pages := make(map[string][]Resource)
+	for u, page := range graph.Pages {
+		if len(page.Site.MixedContent) > 0 {
+			pages[u] = page.Site.MixedContent
+		}
+	}
+
+	return pages
+}
+
+// MixedContentRule flags the HTTP resources and links of HTTPS pages. Active
+// mixed content and insecure forms are errors, as browsers block them or warn
+// before submitting; HTTP images and links are warnings. It only finds
+// something in crawls with CrawlerOpt.MixedContent set.
+type MixedContentRule struct{}
+
+func (rule MixedContentRule) Name() string { return "mixed_content" }
+
+func (rule MixedContentRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, u := range graph.URLs() {
+		for _, resource := range graph.Pages[u].Site.MixedContent {
+			finding := Finding{Severity: SeverityWarning, URL: u}
+			switch {
+			case resource.Kind.Active():
+				finding.Severity = SeverityError
+				finding.Message = fmt.Sprintf("The %s %s is loaded over HTTP, browsers block it.", resource.Kind, resource.URL)
+			case resource.Kind == ResourceForm:
+				finding.Severity = SeverityError
+				finding.Message = fmt.Sprintf("A form submits to %s over HTTP.", resource.URL)
+			case resource.Kind == ResourceLink:
+				finding.Message = fmt.Sprintf("A link to %s downgrades to HTTP.", resource.URL)
+			default:
+				finding.Message = fmt.Sprintf("The %s %s is loaded over HTTP.", resource.Kind, resource.URL)
+			}
+			finding.Message += fmt.Sprintf(" At %s.", resource.Path)
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
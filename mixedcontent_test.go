@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const mixedContentPage = `<html><head>
+	<script src="http://cdn.example.com/app.js"></script>
+	<script>var inline = true;</script>
+	<link rel="stylesheet" href="//cdn.example.com/style.css">
+	<link rel="icon" href="http://cdn.example.com/favicon.ico">
+</head><body>
+	<img src="/logo.png" srcset="http://cdn.example.com/logo-2x.png 2x, /logo-3x.png 3x">
+	<iframe src="http://maps.example.com/embed"></iframe>
+	<form action="http://monzo.com/login"></form>
+	<a href="http://monzo.com/about"></a>
+	<a href="mailto:help@monzo.com">Email us</a>
+	<a href="/legal">Legal</a>
+</body></html>`
+
+func TestExtractResources(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(mixedContentPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	siteURL, _ := url.Parse("https://monzo.com/")
+
+	want := []Resource{
+		{ResourceScript, "http://cdn.example.com/app.js", "html > head > script:nth-of-type(1)"},
+		{ResourceStylesheet, "https://cdn.example.com/style.css", "html > head > link:nth-of-type(1)"},
+		{ResourceImage, "https://monzo.com/logo.png", "html > body > img"},
+		{ResourceImage, "http://cdn.example.com/logo-2x.png", "html > body > img"},
+		{ResourceImage, "https://monzo.com/logo-3x.png", "html > body > img"},
+		{ResourceIframe, "http://maps.example.com/embed", "html > body > iframe"},
+		{ResourceForm, "http://monzo.com/login", "html > body > form"},
+		{ResourceLink, "http://monzo.com/about", "html > body > a:nth-of-type(1)"},
+		{ResourceLink, "https://monzo.com/legal", "html > body > a:nth-of-type(3)"},
+	}
+	if got := ExtractResources(root, siteURL); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractResources() = %v, want %v", got, want)
+	}
+}
+
+func TestFindMixedContent(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(mixedContentPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		siteURL string
+		want    []string
+	}{
+		{"https page", "https://monzo.com/", []string{
+			"http://cdn.example.com/app.js",
+			"http://cdn.example.com/logo-2x.png",
+			"http://maps.example.com/embed",
+			"http://monzo.com/login",
+			"http://monzo.com/about",
+		}},
+		{"http page", "http://monzo.com/", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			siteURL, _ := url.Parse(tt.siteURL)
+
+			var got []string
+			for _, resource := range FindMixedContent(root, siteURL) {
+				got = append(got, resource.URL)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindMixedContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_MixedContent(t *testing.T) {
+	insecure := []Resource{{ResourceImage, "http://cdn.example.com/logo.png", "html > body > img"}}
+	graph := &Graph{Pages: map[string]*Page{
+		"https://monzo.com":       {URL: "https://monzo.com", Site: Site{MixedContent: insecure}},
+		"https://monzo.com/about": {URL: "https://monzo.com/about"},
+	}}
+
+	want := map[string][]Resource{"https://monzo.com": insecure}
+	if got := graph.MixedContent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Graph.MixedContent() = %v, want %v", got, want)
+	}
+}
+
+func TestMixedContentRule_Check(t *testing.T) {
+	graph := &Graph{Pages: map[string]*Page{
+		"https://monzo.com": {URL: "https://monzo.com", Site: Site{MixedContent: []Resource{
+			{ResourceScript, "http://cdn.example.com/app.js", "html > head > script"},
+			{ResourceImage, "http://cdn.example.com/logo.png", "html > body > img"},
+			{ResourceForm, "http://monzo.com/login", "html > body > form"},
+			{ResourceLink, "http://monzo.com/about", "html > body > a"},
+		}}},
+		"https://monzo.com/about": {URL: "https://monzo.com/about"},
+	}}
+
+	want := []Finding{
+		{Severity: SeverityError, URL: "https://monzo.com", Message: "The script http://cdn.example.com/app.js is loaded over HTTP, browsers block it. At html > head > script."},
+		{Severity: SeverityWarning, URL: "https://monzo.com", Message: "The image http://cdn.example.com/logo.png is loaded over HTTP. At html > body > img."},
+		{Severity: SeverityError, URL: "https://monzo.com", Message: "A form submits to http://monzo.com/login over HTTP. At html > body > form."},
+		{Severity: SeverityWarning, URL: "https://monzo.com", Message: "A link to http://monzo.com/about downgrades to HTTP. At html > body > a."},
+	}
+	if got := (MixedContentRule{}).Check(graph); !reflect.DeepEqual(got, want) {
+		t.Errorf("MixedContentRule.Check() = %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_Crawl_mixedContent(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><img src="http://cdn.example.com/logo.png"></body></html>`))
+	})
+
+	tests := []struct {
+		name         string
+		server       *httptest.Server
+		mixedContent bool
+		want         []Resource
+	}{
+		{"https", httptest.NewTLSServer(handler), true, []Resource{{ResourceImage, "http://cdn.example.com/logo.png", "html > body > img"}}},
+		{"http", httptest.NewServer(handler), true, nil},
+		{"disabled", httptest.NewTLSServer(handler), false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.server.Close()
+
+			crawler := NewCrawler(context.Background(), CrawlerOpt{HTTPClient: tt.server.Client(), MixedContent: tt.mixedContent})
+			site, err := crawler.Crawl(context.Background(), CrawlQuery{Site: tt.server.URL}, 0)
+			if err != nil {
+				t.Fatalf("Crawler.Crawl() error = %v", err)
+			}
+			if !reflect.DeepEqual(site.MixedContent, tt.want) {
+				t.Errorf("Crawler.Crawl() mixed content = %v, want %v", site.MixedContent, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress is how far the breadth-first crawl of a crawler has got. Queued is
+// the number of URLs waiting in the frontier.
+type Progress struct {
+	Site    string    `json:"site"`
+	Started time.Time `json:"started"`
+	Pages   int       `json:"pages"`
+	Failed  int       `json:"failed"`
+	Queued  int       `json:"queued"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// Elapsed is how long the crawl has been running at now.
+func (p Progress) Elapsed(now time.Time) time.Duration {
+	if p.Started.IsZero() {
+		return 0
+	}
+
+	return now.Sub(p.Started)
+}
+
+type progressTracker struct {
+	mutex    *sync.Mutex
+	progress Progress
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (tracker *progressTracker) update(f func(p *Progress)) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	f(&tracker.progress)
+}
+
+// Progress returns how far the current breadth-first crawl has got. It is
+// safe to call while the crawl is running.
+func (crawler *Crawler) Progress() Progress {
+	crawler.progress.mutex.Lock()
+	defer crawler.progress.mutex.Unlock()
+
+	return crawler.progress.progress
+}
@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Redirect is a hop of a redirect chain: the URL the page was redirected to
+// and the status code of the redirect.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// redirectChain lists the redirects followed to get resp, first hop first.
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]Redirect{{URL: req.URL.String(), StatusCode: req.Response.StatusCode}}, chain...)
+	}
+
+	return chain
+}
+
+// finalURL is the URL resp was served from once redirects were followed, or
+// siteURL when resp doesn't tell.
+func finalURL(siteURL *url.URL, resp *http.Response) *url.URL {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return siteURL
+	}
+
+	return resp.Request.URL
+}
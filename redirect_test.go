@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_redirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/old", http.RedirectHandler("/moved", http.StatusMovedPermanently))
+	mux.Handle("/moved", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		path string
+		want []Redirect
+	}{
+		{"no redirect", "/new", nil},
+		{"redirect chain", "/old", []Redirect{
+			{URL: server.URL + "/moved", StatusCode: http.StatusMovedPermanently},
+			{URL: server.URL + "/new", StatusCode: http.StatusFound},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req.WithContext(context.Background()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := redirectChain(resp); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redirectChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
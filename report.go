@@ -0,0 +1,218 @@
+package crawler
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// maxSlowPages is the number of pages in the slowest pages table of a report.
+var maxSlowPages = 10
+
+// reportSummary are the figures at the top of a report.
+type reportSummary struct {
+	Pages           int
+	URLs            int
+	Broken          int
+	Redirected      int
+	MixedContent    int
+	Unvisited       int
+	Trapped         int
+	StoppedBy       StopReason
+	AverageResponse time.Duration
+}
+
+type reportData struct {
+	Root         string
+	Summary      reportSummary
+	Site         Site
+	Broken       []BrokenLink
+	Redirected   []*Page
+	MixedContent []*Page
+	Slowest      []*Page
+}
+
+// WriteReport writes result as a single HTML page with no external assets:
+// summary figures, the collapsible site tree, the broken links, the
+// redirected pages, the pages with mixed content and the slowest pages.
+func WriteReport(w io.Writer, result CrawlResult) error {
+	graph := NewResultGraph(result)
+
+	data := reportData{
+		Root:   graph.Root,
+		Site:   result.Site,
+		Broken: result.Broken,
+		Summary: reportSummary{
+			Pages:     result.Pages,
+			URLs:      len(graph.Pages),
+			Broken:    len(result.Broken),
+			Unvisited: len(result.Unvisited),
+			Trapped:   len(result.Trapped),
+			StoppedBy: result.StoppedBy,
+		},
+	}
+
+	var total time.Duration
+	for _, u := range graph.URLs() {
+		page := graph.Pages[u]
+		if len(page.Site.Redirects) > 0 {
+			data.Redirected = append(data.Redirected, page)
+		}
+		if len(page.Site.MixedContent) > 0 {
+			data.MixedContent = append(data.MixedContent, page)
+		}
+		if page.Site.ResponseTime > 0 {
+			data.Slowest = append(data.Slowest, page)
+			total += page.Site.ResponseTime
+		}
+	}
+	data.Summary.Redirected = len(data.Redirected)
+	data.Summary.MixedContent = len(data.MixedContent)
+	if len(data.Slowest) > 0 {
+		data.Summary.AverageResponse = total / time.Duration(len(data.Slowest))
+	}
+
+	sort.SliceStable(data.Slowest, func(i, j int) bool {
+		return data.Slowest[i].Site.ResponseTime > data.Slowest[j].Site.ResponseTime
+	})
+	if len(data.Slowest) > maxSlowPages {
+		data.Slowest = data.Slowest[:maxSlowPages]
+	}
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("Failed to render report. { %v }", err)
+	}
+
+	return nil
+}
+
+// milliseconds formats d in milliseconds, like 12.5 ms.
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f ms", float64(d)/float64(time.Millisecond))
+}
+
+// statusClass is the CSS class of a status code: ok, redirect or error.
+func statusClass(status int) string {
+	switch {
+	case status >= 400:
+		return "error"
+	case status >= 300 && status != 304:
+		return "redirect"
+	default:
+		return "ok"
+	}
+}
+
+// reportStyle is the style sheet shared by the HTML reports, inlined so a
+// report is a single file.
+var reportStyle = template.CSS(`body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+td.number { text-align: right; }
+ul.tree, ul.tree ul { list-style: none; padding-left: 1.2em; margin: 0; }
+ul.tree li { margin: 2px 0; }
+summary { cursor: pointer; }
+.status { font-size: 0.85em; padding: 0 4px; border-radius: 3px; }
+.status.ok { background: #cfc; }
+.status.redirect { background: #cdf; }
+.status.error { background: #fcc; }
+.status.warning { background: #ffd9a0; }
+.status.info { background: #eee; }
+.empty { color: #888; }`)
+
+// reportFuncs are the functions of the HTML report templates.
+var reportFuncs = template.FuncMap{
+	"ms":          milliseconds,
+	"statusClass": statusClass,
+	"style":       func() template.CSS { return reportStyle },
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Crawl report: {{.Root}}</title>
+<style>
+{{style}}
+</style>
+</head>
+<body>
+<h1>Crawl report: {{.Root}}</h1>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Pages crawled</th><td class="number">{{.Summary.Pages}}</td></tr>
+<tr><th>URLs found</th><td class="number">{{.Summary.URLs}}</td></tr>
+<tr><th>Broken links</th><td class="number">{{.Summary.Broken}}</td></tr>
+<tr><th>Redirected pages</th><td class="number">{{.Summary.Redirected}}</td></tr>
+<tr><th>Pages with mixed content</th><td class="number">{{.Summary.MixedContent}}</td></tr>
+<tr><th>Unvisited</th><td class="number">{{.Summary.Unvisited}}</td></tr>
+<tr><th>Trapped</th><td class="number">{{.Summary.Trapped}}</td></tr>
+<tr><th>Average response time</th><td class="number">{{ms .Summary.AverageResponse}}</td></tr>
+{{- if .Summary.StoppedBy}}
+<tr><th>Stopped by</th><td>{{.Summary.StoppedBy}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Site tree</h2>
+<ul class="tree"><li>{{template "site" .Site}}</li></ul>
+
+<h2>Broken links</h2>
+{{if .Broken -}}
+<table>
+<tr><th>URL</th><th>Status</th><th>Error</th><th>Linked from</th></tr>
+{{range .Broken -}}
+<tr><td>{{.URL}}</td><td>{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td>{{.Error}}</td><td>{{range .Sources}}{{.}}<br>{{end}}</td></tr>
+{{end -}}
+</table>
+{{- else -}}
+<p class="empty">No broken links.</p>
+{{- end}}
+
+<h2>Redirects</h2>
+{{if .Redirected -}}
+<table>
+<tr><th>URL</th><th>Redirects</th><th>Final status</th></tr>
+{{range .Redirected -}}
+<tr><td>{{.URL}}</td><td>{{range .Site.Redirects}}{{.StatusCode}} &rarr; {{.URL}}<br>{{end}}</td><td>{{.Site.StatusCode}}</td></tr>
+{{end -}}
+</table>
+{{- else -}}
+<p class="empty">No redirects.</p>
+{{- end}}
+
+<h2>Mixed content</h2>
+{{if .MixedContent -}}
+<table>
+<tr><th>URL</th><th>Insecure resources</th></tr>
+{{range .MixedContent -}}
+<tr><td>{{.URL}}</td><td>{{range .Site.MixedContent}}{{.Kind}} {{.URL}}<br>{{end}}</td></tr>
+{{end -}}
+</table>
+{{- else -}}
+<p class="empty">No mixed content.</p>
+{{- end}}
+
+<h2>Slowest pages</h2>
+{{if .Slowest -}}
+<table>
+<tr><th>URL</th><th>Response time</th><th>Status</th></tr>
+{{range .Slowest -}}
+<tr><td>{{.URL}}</td><td class="number">{{ms .Site.ResponseTime}}</td><td>{{.Site.StatusCode}}</td></tr>
+{{end -}}
+</table>
+{{- else -}}
+<p class="empty">No fetched pages.</p>
+{{- end}}
+</body>
+</html>
+{{define "page"}}{{.Data.URL}}{{if .StatusCode}} <span class="status {{statusClass .StatusCode}}">{{.StatusCode}}</span>{{end}}{{end}}
+{{- define "site"}}{{if .Sites}}<details open><summary>{{template "page" .}}</summary>
+<ul>{{range .Sites}}<li>{{template "site" .}}</li>{{end}}</ul>
+</details>{{else}}{{template "page" .}}{{end}}{{end}}
+`))
@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	result := exportResult()
+	result.Site.Sites[0].Redirects = []Redirect{{URL: "https://monzo.com/blog/a", StatusCode: 301}}
+	result.Site.MixedContent = []Resource{{ResourceScript, "http://cdn.example.com/app.js", "html > head > script"}}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	got := buf.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"title", "<title>Crawl report: https://monzo.com</title>"},
+		{"pages crawled", `<tr><th>Pages crawled</th><td class="number">2</td></tr>`},
+		{"urls found", `<tr><th>URLs found</th><td class="number">3</td></tr>`},
+		{"collapsible tree", `<details open><summary>https://monzo.com <span class="status ok">200</span></summary>`},
+		{"broken link", "<tr><td>https://monzo.com/missing</td><td>404</td><td>not found</td><td>https://monzo.com<br></td></tr>"},
+		{"redirect", "<tr><td>https://monzo.com/blog/a</td><td>301 &rarr; https://monzo.com/blog/a<br></td><td>200</td></tr>"},
+		{"mixed content", "<tr><td>https://monzo.com</td><td>script http://cdn.example.com/app.js<br></td></tr>"},
+		{"slowest page", `<tr><td>https://monzo.com/blog/a</td><td class="number">12.5 ms</td><td>200</td></tr>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("WriteReport() = %v, want it to contain %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, external := range []string{"<script", "<link", "<img", "@import", "url("} {
+		if strings.Contains(got, external) {
+			t.Errorf("WriteReport() references an external asset: %v", external)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+func (query CrawlQuery) hasScope() bool {
+	return len(query.Include) > 0 || len(query.Exclude) > 0
+}
+
+// scope decides which links of a query are followed.
+type scope struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newScope(query CrawlQuery) (*scope, error) {
+	s := &scope{}
+
+	for _, pattern := range query.Include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Include pattern is not valid ( %s ). { %v }", pattern, err)
+		}
+		s.include = append(s.include, re)
+	}
+
+	for _, pattern := range query.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Exclude pattern is not valid ( %s ). { %v }", pattern, err)
+		}
+		s.exclude = append(s.exclude, re)
+	}
+
+	return s, nil
+}
+
+// allows tells whether siteURL matches an include pattern, or there are none,
+// and matches no exclude pattern.
+func (s *scope) allows(siteURL *url.URL) bool {
+	u := siteURL.String()
+
+	for _, re := range s.exclude {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+
+	if len(s.include) == 0 {
+		return true
+	}
+
+	for _, re := range s.include {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+
+	return false
+}
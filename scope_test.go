@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_scope_allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   CrawlQuery
+		siteURL string
+		want    bool
+		wantErr bool
+	}{
+		{"no patterns", CrawlQuery{}, "http://example.com/a", true, false},
+		{"included", CrawlQuery{Include: []string{"^http://example.com/"}}, "http://example.com/a", true, false},
+		{"not included", CrawlQuery{Include: []string{"^http://example.com/"}}, "http://example.org/a", false, false},
+		{"any include matches", CrawlQuery{Include: []string{"/blog/", "/docs/"}}, "http://example.com/docs/a", true, false},
+		{"excluded", CrawlQuery{Exclude: []string{`\.pdf$`}}, "http://example.com/a.pdf", false, false},
+		{"exclude wins over include", CrawlQuery{Include: []string{"example.com"}, Exclude: []string{"/private/"}}, "http://example.com/private/a", false, false},
+		{"invalid include", CrawlQuery{Include: []string{"("}}, "http://example.com/a", false, true},
+		{"invalid exclude", CrawlQuery{Exclude: []string{"["}}, "http://example.com/a", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newScope(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			siteURL, _ := url.Parse(tt.siteURL)
+			if got := s.allows(siteURL); got != tt.want {
+				t.Errorf("scope.allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
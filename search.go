@@ -0,0 +1,311 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yhat/scrape"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// bm25K1 and bm25B tune the BM25 ranking of search results: how fast term
+// frequency saturates and how much document length counts.
+var bm25K1 = 1.2
+var bm25B = 0.75
+
+// snippetWords is the number of words of result snippets, and snippetLead how
+// many of them come before the first match.
+var snippetWords = 30
+var snippetLead = 10
+
+// titleBoost is how much more a match in the title weighs than one in the
+// text.
+var titleBoost = 2.0
+
+// indexDocument is a page of an Index. Words are the words of its text as
+// they appear, kept for snippets, Terms their normalized form and length the
+// number of terms indexed for its text and title.
+type indexDocument struct {
+	URL    string
+	Title  string
+	Words  []string
+	Terms  []string
+	length int
+}
+
+// SearchResult is a page matching a search, with its BM25 score and a snippet
+// of its text around the first match.
+type SearchResult struct {
+	URL     string  `json:"url"`
+	Title   string  `json:"title"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// Index is an inverted index of the text of pages, safe for concurrent use.
+// Set it as CrawlerOpt.Index to index the pages of crawls.
+type Index struct {
+	mutex     *sync.Mutex
+	documents map[string]*indexDocument
+	postings  map[string]map[string]int
+	terms     int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{
+		mutex:     &sync.Mutex{},
+		documents: make(map[string]*indexDocument),
+		postings:  make(map[string]map[string]int),
+	}
+}
+
+// Len is the number of pages in the index.
+func (index *Index) Len() int {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return len(index.documents)
+}
+
+// Has tells whether the page at u is in the index.
+func (index *Index) Has(u string) bool {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	_, ok := index.documents[u]
+	return ok
+}
+
+// Add indexes the page at u with its title and text, replacing the page
+// indexed at u before.
+func (index *Index) Add(u, title, text string) {
+	document := &indexDocument{URL: u, Title: strings.TrimSpace(title), Words: strings.Fields(text)}
+	for _, word := range document.Words {
+		document.Terms = append(document.Terms, normalizeWord(word))
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.remove(u)
+	index.documents[u] = document
+
+	terms := document.indexedTerms()
+	for _, term := range terms {
+		if index.postings[term] == nil {
+			index.postings[term] = make(map[string]int)
+		}
+		index.postings[term][u]++
+	}
+	document.length = len(terms)
+	index.terms += document.length
+}
+
+// AddPage indexes the title and body text of the page at u from its DOM.
+func (index *Index) AddPage(u string, root *html.Node) {
+	var title, text string
+	if node, ok := scrape.Find(root, scrape.ByTag(atom.Title)); ok {
+		title = scrape.Text(node)
+	}
+	if body, ok := scrape.Find(root, scrape.ByTag(atom.Body)); ok {
+		text = strings.Join(textWords(body), " ")
+	}
+
+	index.Add(u, title, text)
+}
+
+// indexedTerms are the terms of the text of the document, then of its title.
+func (document *indexDocument) indexedTerms() []string {
+	terms := make([]string, 0, len(document.Terms))
+	for _, term := range document.Terms {
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	for _, word := range strings.Fields(document.Title) {
+		if term := normalizeWord(word); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+func (index *Index) remove(u string) {
+	document, ok := index.documents[u]
+	if !ok {
+		return
+	}
+
+	for _, term := range document.indexedTerms() {
+		delete(index.postings[term], u)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+		}
+	}
+	index.terms -= document.length
+	delete(index.documents, u)
+}
+
+// Search returns the pages matching any word of query, best first, ranked
+// by BM25 with matches in the title counting more. limit caps the results
+// when it is positive.
+func (index *Index) Search(query string, limit int) []SearchResult {
+	terms := queryTerms(query)
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	results := []SearchResult{}
+	if len(terms) == 0 || len(index.documents) == 0 {
+		return results
+	}
+
+	n := float64(len(index.documents))
+	averageLength := math.Max(float64(index.terms)/n, 1)
+
+	scores := make(map[string]float64)
+	for term := range terms {
+		postings := index.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for u, frequency := range postings {
+			length := float64(index.documents[u].length)
+			tf := float64(frequency)
+			scores[u] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/averageLength))
+		}
+	}
+
+	for u := range scores {
+		document := index.documents[u]
+		for _, word := range strings.Fields(document.Title) {
+			if terms[normalizeWord(word)] {
+				scores[u] *= titleBoost
+				break
+			}
+		}
+	}
+
+	for u, score := range scores {
+		document := index.documents[u]
+		results = append(results, SearchResult{
+			URL:     u,
+			Title:   document.Title,
+			Score:   score,
+			Snippet: document.snippet(terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].URL < results[j].URL
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// queryTerms are the distinct normalized words of query.
+func queryTerms(query string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range strings.Fields(query) {
+		if term := normalizeWord(word); term != "" {
+			terms[term] = true
+		}
+	}
+
+	return terms
+}
+
+// snippet is snippetWords words of the text of the document around its
+// first match of terms, with ellipses where the text is cut.
+func (document *indexDocument) snippet(terms map[string]bool) string {
+	first := 0
+	for i, term := range document.Terms {
+		if terms[term] {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetLead
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(document.Words) {
+		end = len(document.Words)
+	}
+
+	snippet := strings.Join(document.Words[start:end], " ")
+	if start > 0 {
+		snippet = "… " + snippet
+	}
+	if end < len(document.Words) {
+		snippet += " …"
+	}
+
+	return snippet
+}
+
+// savedDocument is a page of an index file.
+type savedDocument struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// Save writes the pages of the index to file, as JSON, so LoadIndex can read
+// them back.
+func (index *Index) Save(file string) error {
+	index.mutex.Lock()
+	documents := make([]savedDocument, 0, len(index.documents))
+	for _, document := range index.documents {
+		documents = append(documents, savedDocument{URL: document.URL, Title: document.Title, Text: strings.Join(document.Words, " ")})
+	}
+	index.mutex.Unlock()
+
+	sort.Slice(documents, func(i, j int) bool { return documents[i].URL < documents[j].URL })
+
+	data, err := json.Marshal(documents)
+	if err != nil {
+		return fmt.Errorf("Failed to encode index. { %v }", err)
+	}
+
+	return writeFileAtomic(file, data)
+}
+
+// LoadIndex reads an index saved to file.
+func LoadIndex(file string) (*Index, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read index ( %s ). { %v }", file, err)
+	}
+
+	var documents []savedDocument
+	if err := json.Unmarshal(data, &documents); err != nil {
+		return nil, fmt.Errorf("Failed to decode index ( %s ). { %v }", file, err)
+	}
+
+	index := NewIndex()
+	for _, document := range documents {
+		index.Add(document.URL, document.Title, document.Text)
+	}
+
+	return index, nil
+}
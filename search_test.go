@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func searchIndex() *Index {
+	index := NewIndex()
+	index.Add("/pricing", "Pricing", "Plans start at five pounds a month. Every plan includes a card.")
+	index.Add("/cards", "Cards", "Order a card, freeze your card and change the PIN of your card.")
+	index.Add("/about", "About us", "We are building a bank that works for everyone.")
+	index.Add("/empty", "", "")
+
+	return index
+}
+
+func resultURLs(results []SearchResult) []string {
+	urls := []string{}
+	for _, result := range results {
+		urls = append(urls, result.URL)
+	}
+
+	return urls
+}
+
+func TestIndex_Search(t *testing.T) {
+	index := searchIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"empty query", "  ", 0, []string{}},
+		{"no match", "mortgage", 0, []string{}},
+		{"more frequent first", "card", 0, []string{"/cards", "/pricing"}},
+		{"title matches count more", "Pricing card", 0, []string{"/pricing", "/cards"}},
+		{"case and punctuation", "BANK!", 0, []string{"/about"}},
+		{"title only", "us", 0, []string{"/about"}},
+		{"limit", "card", 1, []string{"/cards"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultURLs(index.Search(tt.query, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Index.Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_Search_snippet(t *testing.T) {
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = "lorem"
+	}
+	words[50] = "needle"
+
+	index := NewIndex()
+	index.Add("/long", "Long", strings.Join(words, " "))
+	index.Add("/short", "Short", "A needle in a haystack.")
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"cut on both sides", "/long", "… " + strings.Join(words[40:70], " ") + " …"},
+		{"whole text", "/short", "A needle in a haystack."},
+	}
+	results := index.Search("needle", 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, result := range results {
+				if result.URL == tt.url && result.Snippet != tt.want {
+					t.Errorf("SearchResult.Snippet = %q, want %q", result.Snippet, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_Add_replaces(t *testing.T) {
+	index := searchIndex()
+	index.Add("/cards", "Cards", "Nothing to see here.")
+
+	if got := index.Len(); got != 4 {
+		t.Errorf("Index.Len() = %v, want %v", got, 4)
+	}
+	if got := resultURLs(index.Search("card", 0)); !reflect.DeepEqual(got, []string{"/pricing"}) {
+		t.Errorf("Index.Search() = %v, want %v", got, []string{"/pricing"})
+	}
+}
+
+func TestIndex_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawler-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "index.json")
+	index := searchIndex()
+	if err := index.Save(file); err != nil {
+		t.Fatalf("Index.Save() error = %v", err)
+	}
+
+	loaded, err := LoadIndex(file)
+	if err != nil {
+		t.Fatalf("LoadIndex() error = %v", err)
+	}
+	if got, want := loaded.Search("card bank", 0), index.Search("card bank", 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadIndex().Search() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadIndex(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("LoadIndex() error = nil, want an error for a missing file")
+	}
+}
+
+func TestCrawler_Crawl_index(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Home</title></head><body><script>var hidden;</script>Hello world</body></html>`))
+	}))
+	defer server.Close()
+
+	index := NewIndex()
+	if _, err := NewCrawler(context.Background(), CrawlerOpt{Index: index}).Crawl(context.Background(), CrawlQuery{Site: server.URL}, 0); err != nil {
+		t.Fatalf("Crawler.Crawl() error = %v", err)
+	}
+
+	want := []SearchResult{{URL: server.URL, Title: "Home", Snippet: "Hello world"}}
+	got := index.Search("hello", 0)
+	for i := range got {
+		got[i].Score = 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Index.Search() = %v, want %v", got, want)
+	}
+	if got := index.Search("hidden", 0); len(got) != 0 {
+		t.Errorf("Index.Search() = %v, want no results for script text", got)
+	}
+}
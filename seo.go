@@ -0,0 +1,219 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxTitleLength is the length, in characters, past which search
+// engines cut titles.
+var DefaultMaxTitleLength = 60
+
+// DefaultMaxClicks is how many clicks from the root every page should be.
+var DefaultMaxClicks = 3
+
+// DefaultAuditRules are the rules an audit runs by default: the SEO rules, the
+// accessibility issues of linted pages and mixed content.
+func DefaultAuditRules() []AuditRule {
+	return []AuditRule{
+		TitleRule{MaxLength: DefaultMaxTitleLength},
+		DescriptionRule{},
+		H1Rule{},
+		ClickDepthRule{MaxClicks: DefaultMaxClicks},
+		CanonicalRule{},
+		RedirectChainRule{},
+		AccessibilityRule{},
+		MixedContentRule{},
+	}
+}
+
+// TitleRule flags pages without a title, with the title of another page or
+// with a title longer than MaxLength characters. MaxLength 0 doesn't limit it.
+type TitleRule struct {
+	MaxLength int
+}
+
+func (rule TitleRule) Name() string { return "title" }
+
+func (rule TitleRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, page := range parsedPages(graph) {
+		title := page.Site.Metadata.Title
+		switch {
+		case title == "":
+			findings = append(findings, Finding{Severity: SeverityError, URL: page.URL, Message: "Page has no title."})
+		case rule.MaxLength > 0 && len([]rune(title)) > rule.MaxLength:
+			findings = append(findings, Finding{Severity: SeverityWarning, URL: page.URL, Message: fmt.Sprintf("Title is %d characters long, more than %d.", len([]rune(title)), rule.MaxLength)})
+		}
+	}
+
+	return append(findings, duplicates(graph, "title", func(metadata *Metadata) string { return metadata.Title })...)
+}
+
+// DescriptionRule flags pages without a meta description or with the
+// description of another page.
+type DescriptionRule struct{}
+
+func (rule DescriptionRule) Name() string { return "description" }
+
+func (rule DescriptionRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, page := range parsedPages(graph) {
+		if page.Site.Metadata.Description == "" {
+			findings = append(findings, Finding{Severity: SeverityWarning, URL: page.URL, Message: "Page has no meta description."})
+		}
+	}
+
+	return append(findings, duplicates(graph, "description", func(metadata *Metadata) string { return metadata.Description })...)
+}
+
+// H1Rule flags pages without a first level heading or with more than one.
+type H1Rule struct{}
+
+func (rule H1Rule) Name() string { return "h1" }
+
+func (rule H1Rule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, page := range parsedPages(graph) {
+		switch h1s := len(page.Site.Metadata.H1); {
+		case h1s == 0:
+			findings = append(findings, Finding{Severity: SeverityWarning, URL: page.URL, Message: "Page has no h1."})
+		case h1s > 1:
+			findings = append(findings, Finding{Severity: SeverityInfo, URL: page.URL, Message: fmt.Sprintf("Page has %d h1s.", h1s)})
+		}
+	}
+
+	return findings
+}
+
+// ClickDepthRule flags pages that can't be reached from the root within
+// MaxClicks clicks. Pages deeper than the crawl aren't in the graph, so the
+// rule only finds something in crawls deeper than MaxClicks, not at the
+// default depth of 2.
+type ClickDepthRule struct {
+	MaxClicks int
+}
+
+func (rule ClickDepthRule) Name() string { return "click_depth" }
+
+func (rule ClickDepthRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, u := range graph.URLs() {
+		if page := graph.Pages[u]; page.Depth > rule.MaxClicks {
+			findings = append(findings, Finding{Severity: SeverityWarning, URL: u, Message: fmt.Sprintf("Page is %d clicks from the root, more than %d.", page.Depth, rule.MaxClicks)})
+		}
+	}
+
+	return findings
+}
+
+// CanonicalRule flags crawled pages whose canonical URL is another page, so
+// they are left out of search results.
+type CanonicalRule struct{}
+
+func (rule CanonicalRule) Name() string { return "canonical" }
+
+func (rule CanonicalRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, page := range parsedPages(graph) {
+		canonical := page.Site.Metadata.Canonical
+		if canonical != "" && !sameURL(canonical, page.URL) {
+			findings = append(findings, Finding{Severity: SeverityInfo, URL: page.URL, Message: fmt.Sprintf("Page is not canonical, its canonical URL is %s.", canonical)})
+		}
+	}
+
+	return findings
+}
+
+// RedirectChainRule flags pages reached through more than one redirect.
+type RedirectChainRule struct{}
+
+func (rule RedirectChainRule) Name() string { return "redirect_chain" }
+
+func (rule RedirectChainRule) Check(graph *Graph) []Finding {
+	var findings []Finding
+
+	for _, u := range graph.URLs() {
+		redirects := graph.Pages[u].Site.Redirects
+		if len(redirects) < 2 {
+			continue
+		}
+
+		hops := make([]string, 0, len(redirects)+1)
+		hops = append(hops, u)
+		for _, redirect := range redirects {
+			hops = append(hops, redirect.URL)
+		}
+		findings = append(findings, Finding{Severity: SeverityWarning, URL: u, Message: fmt.Sprintf("Page redirects %d times: %s.", len(redirects), strings.Join(hops, " -> "))})
+	}
+
+	return findings
+}
+
+// parsedPages are the pages of graph with metadata, sorted by URL.
+func parsedPages(graph *Graph) []*Page {
+	var pages []*Page
+	for _, u := range graph.URLs() {
+		if page := graph.Pages[u]; page.Site.Metadata != nil {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages
+}
+
+// duplicates flags the parsed pages that share a non empty field value.
+func duplicates(graph *Graph, field string, value func(metadata *Metadata) string) []Finding {
+	pages := parsedPages(graph)
+
+	byValue := make(map[string][]string)
+	for _, page := range pages {
+		if v := value(page.Site.Metadata); v != "" {
+			byValue[v] = append(byValue[v], page.URL)
+		}
+	}
+
+	var findings []Finding
+	for _, page := range pages {
+		same := byValue[value(page.Site.Metadata)]
+		if len(same) < 2 {
+			continue
+		}
+
+		others := make([]string, 0, len(same)-1)
+		for _, u := range same {
+			if u != page.URL {
+				others = append(others, u)
+			}
+		}
+		findings = append(findings, Finding{Severity: SeverityWarning, URL: page.URL, Message: fmt.Sprintf("Duplicate %s, also on %s.", field, strings.Join(others, ", "))})
+	}
+
+	return findings
+}
+
+// sameURL tells whether a and b are the same URL, an empty path being the
+// same as /.
+func sameURL(a, b string) bool {
+	parsedA, errA := url.Parse(a)
+	parsedB, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	for _, parsed := range []*url.URL{parsedA, parsedB} {
+		if parsed.Path == "" {
+			parsed.Path = "/"
+		}
+		parsed.Host = strings.ToLower(parsed.Host)
+		parsed.Fragment = ""
+	}
+
+	return parsedA.String() == parsedB.String()
+}
@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// seoGraph is a crawl graph with one page per SEO problem.
+func seoGraph() *Graph {
+	page := func(u string, depth int, metadata *Metadata, redirects ...Redirect) *Page {
+		return &Page{URL: u, Depth: depth, Site: Site{StatusCode: 200, Metadata: metadata, Redirects: redirects}}
+	}
+
+	return &Graph{
+		Root: "https://monzo.com",
+		Pages: map[string]*Page{
+			"https://monzo.com": page("https://monzo.com", 0, &Metadata{
+				Title: "Monzo", Description: "Bank", H1: []string{"Monzo"}, Canonical: "https://monzo.com/",
+			}),
+			"https://monzo.com/a": page("https://monzo.com/a", 1, &Metadata{
+				Title: "Monzo", Description: "Bank", H1: []string{"A", "B"}, Canonical: "https://monzo.com/b",
+			}),
+			"https://monzo.com/b": page("https://monzo.com/b", 4, &Metadata{
+				Title: "A title that is much longer than it should be for search engines", Description: "", H1: nil,
+			}, Redirect{URL: "https://monzo.com/c", StatusCode: 301}, Redirect{URL: "https://monzo.com/d", StatusCode: 302}),
+			"https://monzo.com/file.pdf": page("https://monzo.com/file.pdf", 1, nil, Redirect{URL: "https://monzo.com/files/file.pdf", StatusCode: 301}),
+		},
+	}
+}
+
+func TestAuditRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule AuditRule
+		want []Finding
+	}{
+		{
+			"title",
+			TitleRule{MaxLength: 60},
+			[]Finding{
+				{Severity: SeverityWarning, URL: "https://monzo.com/b", Message: "Title is 64 characters long, more than 60."},
+				{Severity: SeverityWarning, URL: "https://monzo.com", Message: "Duplicate title, also on https://monzo.com/a."},
+				{Severity: SeverityWarning, URL: "https://monzo.com/a", Message: "Duplicate title, also on https://monzo.com."},
+			},
+		},
+		{
+			"description",
+			DescriptionRule{},
+			[]Finding{
+				{Severity: SeverityWarning, URL: "https://monzo.com/b", Message: "Page has no meta description."},
+				{Severity: SeverityWarning, URL: "https://monzo.com", Message: "Duplicate description, also on https://monzo.com/a."},
+				{Severity: SeverityWarning, URL: "https://monzo.com/a", Message: "Duplicate description, also on https://monzo.com."},
+			},
+		},
+		{
+			"h1",
+			H1Rule{},
+			[]Finding{
+				{Severity: SeverityInfo, URL: "https://monzo.com/a", Message: "Page has 2 h1s."},
+				{Severity: SeverityWarning, URL: "https://monzo.com/b", Message: "Page has no h1."},
+			},
+		},
+		{
+			"click depth",
+			ClickDepthRule{MaxClicks: 3},
+			[]Finding{
+				{Severity: SeverityWarning, URL: "https://monzo.com/b", Message: "Page is 4 clicks from the root, more than 3."},
+			},
+		},
+		{
+			"canonical",
+			CanonicalRule{},
+			[]Finding{
+				{Severity: SeverityInfo, URL: "https://monzo.com/a", Message: "Page is not canonical, its canonical URL is https://monzo.com/b."},
+			},
+		},
+		{
+			"redirect chain",
+			RedirectChainRule{},
+			[]Finding{
+				{Severity: SeverityWarning, URL: "https://monzo.com/b", Message: "Page redirects 2 times: https://monzo.com/b -> https://monzo.com/c -> https://monzo.com/d."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Check(seoGraph()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%T.Check() = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sameURL(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{"same", "https://monzo.com/a", "https://monzo.com/a", true},
+		{"empty path", "https://monzo.com", "https://monzo.com/", true},
+		{"host case", "https://Monzo.com/a", "https://monzo.com/a", true},
+		{"fragment", "https://monzo.com/a#top", "https://monzo.com/a", true},
+		{"other path", "https://monzo.com/a", "https://monzo.com/b", false},
+		{"other scheme", "http://monzo.com/a", "https://monzo.com/a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameURL(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClickDepthRule_Check_crawl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><a href="/%d">Next</a></body></html>`, n+1)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     []Finding
+	}{
+		{"default depth", 2, nil},
+		{"deeper than max clicks", 5, []Finding{
+			{Severity: SeverityWarning, URL: server.URL + "/4", Message: "Page is 4 clicks from the root, more than 3."},
+			{Severity: SeverityWarning, URL: server.URL + "/5", Message: "Page is 5 clicks from the root, more than 3."},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), CrawlerOpt{})
+			result, err := crawler.CrawlBreadthFirst(context.Background(), CrawlQuery{Site: server.URL, MaxDepth: tt.maxDepth})
+			if err != nil {
+				t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+			}
+
+			rule := ClickDepthRule{MaxClicks: DefaultMaxClicks}
+			if got := rule.Check(NewResultGraph(result)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClickDepthRule.Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
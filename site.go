@@ -3,6 +3,7 @@ package crawler
 import (
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/ariefrahmansyah/href"
 )
@@ -12,6 +13,27 @@ type Site struct {
 	mutex *sync.Mutex
 	Data  href.Link `json:"data"`
 	Sites []Site    `json:"site,omitempty"`
+	// Truncated is set when the body was cut at the crawler's MaxBodySize.
+	Truncated   bool   `json:"truncated,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// ResponseTime is how long the response took, retries included.
+	ResponseTime time.Duration `json:"response_time,omitempty"`
+	// Redirects are the redirects followed on the way to the page.
+	Redirects []Redirect `json:"redirects,omitempty"`
+	// Metadata is nil for pages that weren't parsed or with DisableMetadata.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Accessibility is set when the crawler lints pages.
+	Accessibility []AccessibilityIssue `json:"accessibility,omitempty"`
+	// Fingerprint is set when the crawler fingerprints pages with text.
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
+	// MixedContent is set for HTTPS pages when the crawler looks for it.
+	MixedContent []Resource `json:"mixed_content,omitempty"`
+	// ETag and LastModified are the validators of the response.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// NotModified is set when the page was unchanged since Previous.
+	NotModified bool `json:"not_modified,omitempty"`
 }
 
 // AppendSite add sitemap to site.
@@ -116,3 +116,19 @@ func TestSortSites(t *testing.T) {
 		})
 	}
 }
+
+// withoutResponseTimes clears the response times of site and its sites, which
+// differ on every crawl.
+func withoutResponseTimes(site Site) Site {
+	site.ResponseTime = 0
+
+	if site.Sites != nil {
+		sites := make([]Site, len(site.Sites))
+		for i, s := range site.Sites {
+			sites[i] = withoutResponseTimes(s)
+		}
+		site.Sites = sites
+	}
+
+	return site
+}
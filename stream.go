@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ariefrahmansyah/href"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// limitedReadCloser reads at most remaining bytes and records whether the
+// underlying body had more.
+type limitedReadCloser struct {
+	io.ReadCloser
+	remaining int64
+	truncated bool
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		if n, _ := l.ReadCloser.Read(probe[:]); n > 0 {
+			l.truncated = true
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// GetLinksStreaming finds the same links as GetLinks, but reads the page with
+// the HTML tokenizer so the DOM is never built.
+func (crawler Crawler) GetLinksStreaming(ctx context.Context, siteURL *url.URL, resp *http.Response, depth int) (map[string]href.Link, error) {
+	links := make(map[string]href.Link)
+
+	var inAnchor bool
+	var aHref string
+	var aText []string
+
+	flush := func() {
+		if inAnchor {
+			crawler.addLink(ctx, links, siteURL, joinText(aText), aHref, depth)
+		}
+		inAnchor, aHref, aText = false, "", nil
+	}
+
+	z := html.NewTokenizer(resp.Body)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return nil, z.Err()
+			}
+			flush()
+			return links, nil
+
+		case html.StartTagToken:
+			token := z.Token()
+			if token.DataAtom != atom.A {
+				continue
+			}
+
+			// An anchor can't contain another one, the parser closes it.
+			flush()
+			inAnchor = true
+			for _, attr := range token.Attr {
+				if attr.Key == "href" {
+					aHref = attr.Val
+				}
+			}
+
+		case html.EndTagToken:
+			if token := z.Token(); token.DataAtom == atom.A {
+				flush()
+			}
+
+		case html.TextToken:
+			if inAnchor {
+				aText = append(aText, string(z.Text()))
+			}
+		}
+	}
+}
+
+// joinText joins the trimmed, non-empty text pieces with a space, the same
+// way scrape.Text does.
+func joinText(pieces []string) string {
+	var trimmed []string
+	for _, piece := range pieces {
+		if piece = strings.TrimSpace(piece); piece != "" {
+			trimmed = append(trimmed, piece)
+		}
+	}
+
+	return strings.Join(trimmed, " ")
+}
@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/ariefrahmansyah/href"
+)
+
+func Test_limitedReadCloser_Read(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		limit         int64
+		want          string
+		wantTruncated bool
+	}{
+		{"shorter than limit", "<html></html>", 100, "<html></html>", false},
+		{"exactly the limit", "<html></html>", 13, "<html></html>", false},
+		{"longer than limit", "<html></html>", 6, "<html>", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &limitedReadCloser{
+				ReadCloser: ioutil.NopCloser(bytes.NewBufferString(tt.body)),
+				remaining:  tt.limit,
+			}
+
+			got, err := ioutil.ReadAll(l)
+			if err != nil {
+				t.Errorf("limitedReadCloser.Read() error = %v", err)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("limitedReadCloser.Read() = %v, want %v", string(got), tt.want)
+			}
+			if l.truncated != tt.wantTruncated {
+				t.Errorf("limitedReadCloser.truncated = %v, want %v", l.truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestCrawler_GetLinksStreaming(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		siteURL *url.URL
+		resp    *http.Response
+		depth   int
+	}
+	tests := []struct {
+		name    string
+		crawler Crawler
+		args    args
+		want    map[string]href.Link
+		wantErr bool
+	}{
+		{
+			"nested text",
+			*defaultCrawler,
+			args{
+				context.Background(),
+				parentURL,
+				&http.Response{
+					Body: ioutil.NopCloser(bytes.NewBuffer([]byte(`
+						<html><body>
+							<a href="/1"><span>1</span></a>
+							<a href="https://monzo.com/2"> 2 <b>two</b> </a>
+						</body></html>`))),
+				},
+				0,
+			},
+			map[string]href.Link{
+				"https://monzo.com/1": href.NewLink(context.Background(), parentURL, "1", "/1", 0),
+				"https://monzo.com/2": href.NewLink(context.Background(), parentURL, "2 two", "https://monzo.com/2", 0),
+			},
+			false,
+		},
+		{
+			"no text, no href and out of domain",
+			*defaultCrawler,
+			args{
+				context.Background(),
+				parentURL,
+				&http.Response{
+					Body: ioutil.NopCloser(bytes.NewBuffer([]byte(`
+						<html><body>
+							<a href="/1">1</a>
+							<a href="https://monzo.com/2"></a>
+							<a href="">3</a>
+							<a href="https://mondo.com">mondo</a>
+						</body></html>`))),
+				},
+				0,
+			},
+			map[string]href.Link{
+				"https://monzo.com/1": href.NewLink(context.Background(), parentURL, "1", "/1", 0),
+			},
+			false,
+		},
+		{
+			"unclosed anchor",
+			*defaultCrawler,
+			args{
+				context.Background(),
+				parentURL,
+				&http.Response{
+					Body: ioutil.NopCloser(bytes.NewBuffer([]byte(`<html><body><a href="/1">1`))),
+				},
+				0,
+			},
+			map[string]href.Link{
+				"https://monzo.com/1": href.NewLink(context.Background(), parentURL, "1", "/1", 0),
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.crawler.GetLinksStreaming(tt.args.ctx, tt.args.siteURL, tt.args.resp, tt.args.depth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Crawler.GetLinksStreaming() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Crawler.GetLinksStreaming() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
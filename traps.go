@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TrapOpt configures crawler trap detection. A zero value disables a check.
+type TrapOpt struct {
+	// MaxRepeatedSegments is how many times one path segment may appear, so
+	// /a/a/a/ is a trap when it is 2.
+	MaxRepeatedSegments int
+	// MaxURLLength is the longest URL that is crawled.
+	MaxURLLength int
+	// MaxQueryVariants is how many distinct query strings one path may have.
+	MaxQueryVariants int
+	// MaxPagesPerPattern is how many pages may share a path pattern, where a
+	// pattern is the path with its numbers replaced, e.g. /calendar/{n}/{n}.
+	MaxPagesPerPattern int
+}
+
+// DefaultTrapOpt is a reasonable trap detection setting for most sites.
+var DefaultTrapOpt = TrapOpt{
+	MaxRepeatedSegments: 3,
+	MaxURLLength:        2048,
+	MaxQueryVariants:    50,
+	MaxPagesPerPattern:  200,
+}
+
+func (opt TrapOpt) enabled() bool {
+	return opt.MaxRepeatedSegments > 0 || opt.MaxURLLength > 0 || opt.MaxQueryVariants > 0 || opt.MaxPagesPerPattern > 0
+}
+
+// TrapReason names the heuristic that flagged a URL as a crawler trap.
+type TrapReason string
+
+const (
+	TrapRepeatedSegments TrapReason = "repeated_segments"
+	TrapURLLength        TrapReason = "url_length"
+	TrapQueryVariants    TrapReason = "query_variants"
+	TrapPathPattern      TrapReason = "path_pattern"
+)
+
+// TrappedURL is a link that was not crawled because it looks like a trap.
+type TrappedURL struct {
+	URL    string     `json:"url"`
+	Reason TrapReason `json:"reason"`
+}
+
+var numberPattern = regexp.MustCompile(`[0-9]+`)
+
+// trapDetector remembers the URLs accepted so far by a crawler, and the ones
+// it flagged.
+type trapDetector struct {
+	opt      TrapOpt
+	mutex    sync.Mutex
+	accepted map[string]bool
+	queries  map[string]map[string]bool
+	patterns map[string]int
+	trapped  map[string]TrapReason
+}
+
+func newTrapDetector(opt TrapOpt) *trapDetector {
+	if !opt.enabled() {
+		return nil
+	}
+
+	return &trapDetector{
+		opt:      opt,
+		accepted: make(map[string]bool),
+		queries:  make(map[string]map[string]bool),
+		patterns: make(map[string]int),
+		trapped:  make(map[string]TrapReason),
+	}
+}
+
+// check tells whether siteURL is a trap. URLs that are not traps are counted
+// once towards the query variant and path pattern limits, however often they
+// are checked. A nil detector accepts every URL.
+func (d *trapDetector) check(siteURL *url.URL) TrapReason {
+	if d == nil {
+		return ""
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := siteURL.Scheme + "://" + siteURL.Host + siteURL.Path + "?" + siteURL.Query().Encode()
+	if d.accepted[key] {
+		return ""
+	}
+
+	reason := d.reason(siteURL)
+	if reason != "" {
+		d.trapped[siteURL.String()] = reason
+		return reason
+	}
+	d.accepted[key] = true
+
+	return ""
+}
+
+// reason tells why siteURL, not accepted yet, is a trap, or counts it when it
+// isn't.
+func (d *trapDetector) reason(siteURL *url.URL) TrapReason {
+	if d.opt.MaxURLLength > 0 && len(siteURL.String()) > d.opt.MaxURLLength {
+		return TrapURLLength
+	}
+
+	if d.opt.MaxRepeatedSegments > 0 && repeatedSegments(siteURL.Path) > d.opt.MaxRepeatedSegments {
+		return TrapRepeatedSegments
+	}
+
+	path := siteURL.Host + siteURL.Path
+	query := siteURL.Query().Encode()
+
+	queries, ok := d.queries[path]
+	if !ok {
+		queries = make(map[string]bool)
+		d.queries[path] = queries
+	}
+	if d.opt.MaxQueryVariants > 0 && !queries[query] && len(queries) >= d.opt.MaxQueryVariants {
+		return TrapQueryVariants
+	}
+
+	pattern := siteURL.Host + numberPattern.ReplaceAllString(siteURL.Path, "{n}")
+	if d.opt.MaxPagesPerPattern > 0 && d.patterns[pattern] >= d.opt.MaxPagesPerPattern {
+		return TrapPathPattern
+	}
+
+	queries[query] = true
+	d.patterns[pattern]++
+
+	return ""
+}
+
+// Trapped lists the links the crawler didn't follow because they look like
+// traps, sorted by URL. It is how depth-first crawls report them, as Crawl
+// only returns a site; CrawlBreadthFirst also returns them in its result.
+func (crawler *Crawler) Trapped() []TrappedURL {
+	return crawler.traps.list()
+}
+
+// list returns the URLs flagged so far, sorted.
+func (d *trapDetector) list() []TrappedURL {
+	if d == nil {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var trapped []TrappedURL
+	for u, reason := range d.trapped {
+		trapped = append(trapped, TrappedURL{URL: u, Reason: reason})
+	}
+	sort.Slice(trapped, func(i, j int) bool { return trapped[i].URL < trapped[j].URL })
+
+	return trapped
+}
+
+// repeatedSegments returns how many times the most frequent segment of path
+// appears in it.
+func repeatedSegments(path string) int {
+	max := 0
+	counts := make(map[string]int)
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+
+		counts[segment]++
+		if counts[segment] > max {
+			max = counts[segment]
+		}
+	}
+
+	return max
+}
@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_trapDetector_check(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  TrapOpt
+		urls []string
+		want []TrapReason
+	}{
+		{
+			"disabled",
+			TrapOpt{},
+			[]string{
+				"https://monzo.com/a/a/a/a",
+			},
+			[]TrapReason{""},
+		},
+		{
+			"repeated segments",
+			TrapOpt{MaxRepeatedSegments: 2},
+			[]string{
+				"https://monzo.com/a/b/a",
+				"https://monzo.com/a/a/a",
+			},
+			[]TrapReason{"", TrapRepeatedSegments},
+		},
+		{
+			"url length",
+			TrapOpt{MaxURLLength: 20},
+			[]string{
+				"https://monzo.com/a",
+				"https://monzo.com/about",
+			},
+			[]TrapReason{"", TrapURLLength},
+		},
+		{
+			"query variants",
+			TrapOpt{MaxQueryVariants: 2},
+			[]string{
+				"https://monzo.com/search?q=1&sid=a",
+				"https://monzo.com/search?sid=a&q=1",
+				"https://monzo.com/search?q=1&sid=b",
+				"https://monzo.com/search?q=1&sid=c",
+				"https://monzo.com/search?q=1&sid=a",
+			},
+			[]TrapReason{"", "", "", TrapQueryVariants, ""},
+		},
+		{
+			"path pattern",
+			TrapOpt{MaxPagesPerPattern: 2},
+			[]string{
+				"https://monzo.com/calendar/2017/01",
+				"https://monzo.com/calendar/2017/02",
+				"https://monzo.com/calendar/2017/03",
+				"https://monzo.com/calendar/2017",
+			},
+			[]TrapReason{"", "", TrapPathPattern, ""},
+		},
+		{
+			"same url counted once",
+			TrapOpt{MaxPagesPerPattern: 2, MaxQueryVariants: 1},
+			[]string{
+				"https://monzo.com/about?a=1&b=2",
+				"https://monzo.com/about?a=1&b=2",
+				"https://monzo.com/about?b=2&a=1",
+				"https://monzo.com/about?a=1&b=2",
+			},
+			[]TrapReason{"", "", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTrapDetector(tt.opt)
+
+			var got []TrapReason
+			for _, u := range tt.urls {
+				siteURL, _ := url.Parse(u)
+				got = append(got, d.check(siteURL))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trapDetector.check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repeatedSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"empty", "", 0},
+		{"root", "/", 0},
+		{"no repeat", "/a/b/c", 1},
+		{"repeat", "/a/b/a/c/a/", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repeatedSegments(tt.path); got != tt.want {
+				t.Errorf("repeatedSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// calendarTrap serves a calendar whose every day links to the next one, and
+// to a page linked from every page.
+func calendarTrap() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next := "/calendar/1"
+		if day := strings.TrimPrefix(r.URL.Path, "/calendar/"); day != r.URL.Path {
+			n, _ := strconv.Atoi(day)
+			next = fmt.Sprintf("/calendar/%d", n+1)
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><a href="/about">About</a><a href="%s">Next</a></body></html>`, next)
+	}))
+}
+
+func TestCrawler_Trapped(t *testing.T) {
+	server := calendarTrap()
+	defer server.Close()
+
+	want := []TrappedURL{{URL: server.URL + "/calendar/3", Reason: TrapPathPattern}}
+
+	tests := []struct {
+		name     string
+		strategy CrawlStrategy
+	}{
+		{"depth first", DepthFirst},
+		{"breadth first", BreadthFirst},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), CrawlerOpt{Strategy: tt.strategy, Traps: TrapOpt{MaxPagesPerPattern: 2}})
+
+			query := CrawlQuery{Site: server.URL, MaxDepth: 6}
+			if tt.strategy == BreadthFirst {
+				result, err := crawler.CrawlBreadthFirst(context.Background(), query)
+				if err != nil {
+					t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+				}
+				if !reflect.DeepEqual(result.Trapped, want) {
+					t.Errorf("CrawlResult.Trapped = %v, want %v", result.Trapped, want)
+				}
+			} else if _, err := crawler.Crawl(context.Background(), query, 0); err != nil {
+				t.Fatalf("Crawler.Crawl() error = %v", err)
+			}
+
+			if got := crawler.Trapped(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Crawler.Trapped() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APIKey is a client allowed to use the service. RatePerMinute is how many
+// requests it may send each minute, with bursts of up to Burst, and MaxCrawls
+// how many crawls it may run at once. Zero limits fall back to the defaults of
+// the config.
+type APIKey struct {
+	Name          string  `json:"name"`
+	Key           string  `json:"key"`
+	RatePerMinute float64 `json:"rate_per_minute"`
+	Burst         int     `json:"burst"`
+	MaxCrawls     int     `json:"max_crawls"`
+}
+
+// loadAPIKeys reads the JSON list of API keys in file.
+func loadAPIKeys(file string) ([]APIKey, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read API keys ( %s ). { %v }", file, err)
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("Failed to parse API keys ( %s ). { %v }", file, err)
+	}
+
+	names := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for _, key := range keys {
+		if key.Name == "" || key.Key == "" {
+			return nil, fmt.Errorf("API key needs a name and a key ( %s )", file)
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("API key name is used twice ( %s )", key.Name)
+		}
+		if secrets[key.Key] {
+			return nil, fmt.Errorf("API key of %s is already used by another key", key.Name)
+		}
+		names[key.Name] = true
+		secrets[key.Key] = true
+	}
+
+	return keys, nil
+}
+
+// tokenBucket allows rate requests per second with bursts of up to burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token at now. When there is none, it returns how long until
+// there is.
+func (bucket *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	bucket.tokens = math.Min(bucket.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*bucket.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / bucket.rate * float64(time.Second))
+	}
+	bucket.tokens--
+
+	return true, 0
+}
+
+// client is the usage of an API key.
+type client struct {
+	key       APIKey
+	bucket    *tokenBucket
+	crawls    int
+	maxCrawls int
+}
+
+// authMetrics counts the requests of every API key.
+type authMetrics struct {
+	requests *prometheus.CounterVec
+	rejected *prometheus.CounterVec
+	crawls   *prometheus.GaugeVec
+}
+
+func newAuthMetrics(registerer prometheus.Registerer) (*authMetrics, error) {
+	metrics := &authMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "crawler",
+			Subsystem: "api",
+			Name:      "requests_total",
+			Help:      "Requests accepted for each API key.",
+		}, []string{"key"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "crawler",
+			Subsystem: "api",
+			Name:      "rejected_total",
+			Help:      "Requests rejected for each API key by reason.",
+		}, []string{"key", "reason"}),
+		crawls: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "crawler",
+			Subsystem: "api",
+			Name:      "active_crawls",
+			Help:      "Crawls running for each API key.",
+		}, []string{"key"}),
+	}
+
+	for _, collector := range []prometheus.Collector{metrics.requests, metrics.rejected, metrics.crawls} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+// Auth is a negroni middleware that lets only requests with a known API key
+// through, in the Authorization header as a bearer token or in X-API-Key. It
+// enforces the rate limit of every key, and its crawl quota on /crawl. The
+// health, readiness and metrics endpoints stay open.
+type Auth struct {
+	mutex   *sync.Mutex
+	clients map[string]*client
+	metrics *authMetrics
+	now     func() time.Time
+}
+
+// NewAuth creates the middleware for keys. Keys without limits get the rate
+// and crawl quota given here.
+func NewAuth(keys []APIKey, ratePerMinute float64, maxCrawls int, registerer prometheus.Registerer) (*Auth, error) {
+	metrics, err := newAuthMetrics(registerer)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to register API metrics. { %v }", err)
+	}
+
+	auth := &Auth{
+		mutex:   &sync.Mutex{},
+		clients: make(map[string]*client),
+		metrics: metrics,
+		now:     time.Now,
+	}
+
+	for _, key := range keys {
+		c := &client{key: key, maxCrawls: maxCrawls}
+		if key.MaxCrawls > 0 {
+			c.maxCrawls = key.MaxCrawls
+		}
+
+		rate := ratePerMinute
+		if key.RatePerMinute > 0 {
+			rate = key.RatePerMinute
+		}
+		burst := key.Burst
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(rate/6)))
+		}
+		c.bucket = &tokenBucket{rate: rate / 60, burst: float64(burst), tokens: float64(burst), last: auth.now()}
+
+		auth.clients[key.Key] = c
+	}
+
+	return auth, nil
+}
+
+var openPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+func requestKey(r *http.Request) string {
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	return r.Header.Get("X-API-Key")
+}
+
+// lookup finds the client of key, comparing keys in constant time.
+func (auth *Auth) lookup(key string) *client {
+	var found *client
+	for k, c := range auth.clients {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			found = c
+		}
+	}
+
+	return found
+}
+
+func (auth *Auth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if openPaths[r.URL.Path] {
+		next(w, r)
+		return
+	}
+
+	key := requestKey(r)
+	if key == "" {
+		writeError(w, http.StatusUnauthorized, APIError{Code: errorUnauthorized, Message: "API key is required"})
+		return
+	}
+
+	auth.mutex.Lock()
+	c := auth.lookup(key)
+	if c == nil {
+		auth.mutex.Unlock()
+		writeError(w, http.StatusUnauthorized, APIError{Code: errorUnauthorized, Message: "API key is not valid"})
+		return
+	}
+	name := c.key.Name
+
+	allowed, wait := c.bucket.take(auth.now())
+	if !allowed {
+		auth.mutex.Unlock()
+		auth.metrics.rejected.WithLabelValues(name, "rate_limit").Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, APIError{Code: errorRateLimited, Message: "Rate limit exceeded"})
+		return
+	}
+
+	crawl := r.URL.Path == "/crawl"
+	if crawl && c.crawls >= c.maxCrawls {
+		auth.mutex.Unlock()
+		auth.metrics.rejected.WithLabelValues(name, "crawl_quota").Inc()
+		writeError(w, http.StatusTooManyRequests, APIError{Code: errorQuotaExceeded, Message: fmt.Sprintf("At most %d crawls may run at once", c.maxCrawls)})
+		return
+	}
+	if crawl {
+		c.crawls++
+	}
+	auth.mutex.Unlock()
+
+	auth.metrics.requests.WithLabelValues(name).Inc()
+
+	if crawl {
+		auth.metrics.crawls.WithLabelValues(name).Inc()
+		defer func() {
+			auth.mutex.Lock()
+			c.crawls--
+			auth.mutex.Unlock()
+			auth.metrics.crawls.WithLabelValues(name).Dec()
+		}()
+	}
+
+	next(w, r)
+}
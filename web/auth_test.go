@@ -0,0 +1,178 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(c prometheus.Collector) float64 {
+	metrics := make(chan prometheus.Metric, 1)
+	c.Collect(metrics)
+	close(metrics)
+
+	m := &dto.Metric{}
+	for metric := range metrics {
+		metric.Write(m)
+	}
+	if m.Counter != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		want    int
+		wantErr bool
+	}{
+		{"valid", `[{"name": "ci", "key": "a"}, {"name": "ops", "key": "b", "max_crawls": 4}]`, 2, false},
+		{"not json", `{`, 0, true},
+		{"missing key", `[{"name": "ci"}]`, 0, true},
+		{"missing name", `[{"key": "a"}]`, 0, true},
+		{"duplicate name", `[{"name": "ci", "key": "a"}, {"name": "ci", "key": "b"}]`, 0, true},
+		{"duplicate key", `[{"name": "ci", "key": "a"}, {"name": "ops", "key": "a"}]`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "crawler-keys")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			file := filepath.Join(dir, "keys.json")
+			if err := ioutil.WriteFile(file, []byte(tt.keys), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadAPIKeys(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadAPIKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("loadAPIKeys() = %v, want %d keys", got, tt.want)
+			}
+		})
+	}
+}
+
+// testAuth is an Auth for the ci key, allowing 60 requests a minute in bursts
+// of 2 and 1 crawl at once, at a clock the test moves.
+func testAuth(t *testing.T) (*Auth, *time.Time) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	auth, err := NewAuth([]APIKey{{Name: "ci", Key: "secret", Burst: 2}}, 60, 1, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewAuth() error = %v", err)
+	}
+	auth.now = func() time.Time { return now }
+	for _, c := range auth.clients {
+		c.bucket.last = now
+	}
+
+	return auth, &now
+}
+
+func serveAuth(auth *Auth, path string, header http.Header, next http.HandlerFunc) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	auth.ServeHTTP(w, r, next)
+
+	return w
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {}
+
+func TestAuth_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		want   int
+	}{
+		{"no key", "/crawl", nil, http.StatusUnauthorized},
+		{"bad key", "/crawl", http.Header{"X-Api-Key": {"wrong"}}, http.StatusUnauthorized},
+		{"bad bearer token", "/diff", http.Header{"Authorization": {"Bearer wrong"}}, http.StatusUnauthorized},
+		{"api key header", "/crawl", http.Header{"X-Api-Key": {"secret"}}, http.StatusOK},
+		{"bearer token", "/diff", http.Header{"Authorization": {"Bearer secret"}}, http.StatusOK},
+		{"healthz is open", "/healthz", nil, http.StatusOK},
+		{"readyz is open", "/readyz", nil, http.StatusOK},
+		{"metrics is open", "/metrics", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, _ := testAuth(t)
+
+			if got := serveAuth(auth, tt.path, tt.header, ok).Code; got != tt.want {
+				t.Errorf("Auth.ServeHTTP() status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuth_ServeHTTP_rateLimit(t *testing.T) {
+	auth, now := testAuth(t)
+	key := http.Header{"X-Api-Key": {"secret"}}
+
+	for i := 0; i < 2; i++ {
+		if got := serveAuth(auth, "/diff", key, ok).Code; got != http.StatusOK {
+			t.Fatalf("request %d status = %v, want %v", i+1, got, http.StatusOK)
+		}
+	}
+
+	w := serveAuth(auth, "/diff", key, ok)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("request over the burst = %v with Retry-After %q, want %v with Retry-After 1", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+
+	*now = now.Add(time.Second)
+	if got := serveAuth(auth, "/diff", key, ok).Code; got != http.StatusOK {
+		t.Errorf("request a second later status = %v, want %v", got, http.StatusOK)
+	}
+
+	if got := counterValue(auth.metrics.requests.WithLabelValues("ci")); got != 3 {
+		t.Errorf("requests = %v, want 3", got)
+	}
+	if got := counterValue(auth.metrics.rejected.WithLabelValues("ci", "rate_limit")); got != 1 {
+		t.Errorf("rate limited = %v, want 1", got)
+	}
+}
+
+func TestAuth_ServeHTTP_crawlQuota(t *testing.T) {
+	auth, _ := testAuth(t)
+	key := http.Header{"X-Api-Key": {"secret"}}
+
+	var nested *httptest.ResponseRecorder
+	var running float64
+	serveAuth(auth, "/crawl", key, func(w http.ResponseWriter, r *http.Request) {
+		running = counterValue(auth.metrics.crawls.WithLabelValues("ci"))
+		nested = serveAuth(auth, "/crawl", key, ok)
+	})
+
+	if running != 1 {
+		t.Errorf("active crawls while crawling = %v, want 1", running)
+	}
+	if nested.Code != http.StatusTooManyRequests {
+		t.Errorf("second crawl status = %v, want %v", nested.Code, http.StatusTooManyRequests)
+	}
+	if got := counterValue(auth.metrics.rejected.WithLabelValues("ci", "crawl_quota")); got != 1 {
+		t.Errorf("quota rejections = %v, want 1", got)
+	}
+	if got := counterValue(auth.metrics.crawls.WithLabelValues("ci")); got != 0 {
+		t.Errorf("active crawls after the crawl = %v, want 0", got)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the web service. Every field is read, in
+// order of precedence, from a command line flag, an environment variable and
+// the JSON config file named by -config or CONFIG_FILE, falling back to
+// defaultConfig.
+type Config struct {
+	Port     string `json:"port"`
+	LogLevel string `json:"log_level"`
+
+	ReadTimeout     Duration `json:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	AllowPrivateNetworks bool     `json:"allow_private_networks"`
+	BlockedCIDRs         List     `json:"blocked_cidrs"`
+	CacheDir             string   `json:"cache_dir"`
+	CacheTTL             Duration `json:"cache_ttl"`
+
+	DefaultDepth   int      `json:"default_depth"`
+	MaxDepth       int      `json:"max_depth"`
+	MaxPages       int      `json:"max_pages"`
+	MaxConcurrency int      `json:"max_concurrency"`
+	MaxDuration    Duration `json:"max_duration"`
+	MaxCrawls      int      `json:"max_crawls"`
+	MaxDiffSize    int      `json:"max_diff_size"`
+
+	APIKeysFile      string  `json:"api_keys_file"`
+	KeyRatePerMinute float64 `json:"key_rate_per_minute"`
+	KeyMaxCrawls     int     `json:"key_max_crawls"`
+
+	SearchIndex     bool   `json:"search_index"`
+	SearchIndexFile string `json:"search_index_file"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:     "8080",
+		LogLevel: "info",
+
+		ReadTimeout:     Duration(10 * time.Second),
+		WriteTimeout:    Duration(6 * time.Minute),
+		IdleTimeout:     Duration(2 * time.Minute),
+		ShutdownTimeout: Duration(30 * time.Second),
+
+		CacheTTL: Duration(time.Hour),
+
+		DefaultDepth:   defaultCrawlDepth,
+		MaxDepth:       maxCrawlDepth,
+		MaxPages:       maxCrawlPages,
+		MaxConcurrency: maxCrawlConcurrency,
+		MaxDuration:    Duration(maxCrawlDuration),
+		MaxCrawls:      maxCrawls,
+		MaxDiffSize:    int(maxDiffSize),
+
+		KeyRatePerMinute: 60,
+		KeyMaxCrawls:     2,
+	}
+}
+
+// Duration is a time.Duration written as "30s" in the config file.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) Set(value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("Duration must be a string like \"30s\". { %v }", err)
+	}
+	return d.Set(value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// List is a comma separated list on the command line and in the environment.
+type List []string
+
+func (l *List) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *List) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// boolValue is a flag.Value of a bool, for setting one from the environment.
+type boolValue struct {
+	b *bool
+}
+
+func (v boolValue) String() string {
+	if v.b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*v.b)
+}
+
+func (v boolValue) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*v.b = b
+	return nil
+}
+
+func (v boolValue) IsBoolFlag() bool {
+	return true
+}
+
+// intValue is a flag.Value of an int, for setting one from the environment.
+type intValue struct {
+	i *int
+}
+
+func (v intValue) String() string {
+	if v.i == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.i)
+}
+
+func (v intValue) Set(value string) error {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*v.i = i
+	return nil
+}
+
+// floatValue is a flag.Value of a float64, for setting one from the
+// environment.
+type floatValue struct {
+	f *float64
+}
+
+func (v floatValue) String() string {
+	if v.f == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*v.f, 'g', -1, 64)
+}
+
+func (v floatValue) Set(value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	*v.f = f
+	return nil
+}
+
+// stringValue is a flag.Value of a string, for setting one from the
+// environment.
+type stringValue struct {
+	s *string
+}
+
+func (v stringValue) String() string {
+	if v.s == nil {
+		return ""
+	}
+	return *v.s
+}
+
+func (v stringValue) Set(value string) error {
+	*v.s = value
+	return nil
+}
+
+// configOption is a Config field with its flag and environment variable.
+type configOption struct {
+	flag  string
+	env   string
+	value flag.Value
+	usage string
+}
+
+func (cfg *Config) options() []configOption {
+	return []configOption{
+		{"port", "PORT", stringValue{&cfg.Port}, "port to listen on"},
+		{"log-level", "LOG_LEVEL", stringValue{&cfg.LogLevel}, "log level: debug, info, warn or error"},
+		{"read-timeout", "READ_TIMEOUT", &cfg.ReadTimeout, "maximum duration for reading a request"},
+		{"write-timeout", "WRITE_TIMEOUT", &cfg.WriteTimeout, "maximum duration for writing a response, longer than max-duration"},
+		{"idle-timeout", "IDLE_TIMEOUT", &cfg.IdleTimeout, "how long idle keep-alive connections stay open"},
+		{"shutdown-timeout", "SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout, "grace period for in-flight crawls on shutdown"},
+		{"allow-private-networks", "ALLOW_PRIVATE_NETWORKS", boolValue{&cfg.AllowPrivateNetworks}, "allow crawling internal addresses"},
+		{"blocked-cidrs", "BLOCKED_CIDRS", &cfg.BlockedCIDRs, "comma separated networks crawls may not connect to"},
+		{"cache-dir", "CACHE_DIR", stringValue{&cfg.CacheDir}, "keep crawled sites in this directory instead of in memory"},
+		{"cache-ttl", "CACHE_TTL", &cfg.CacheTTL, "how long crawled sites are cached"},
+		{"default-depth", "DEFAULT_DEPTH", intValue{&cfg.DefaultDepth}, "depth of crawls that don't ask for one"},
+		{"max-depth", "MAX_DEPTH", intValue{&cfg.MaxDepth}, "maximum depth a crawl may ask for"},
+		{"max-pages", "MAX_PAGES", intValue{&cfg.MaxPages}, "maximum pages a crawl may fetch"},
+		{"max-concurrency", "MAX_CONCURRENCY", intValue{&cfg.MaxConcurrency}, "maximum concurrency a crawl may ask for"},
+		{"max-duration", "MAX_DURATION", &cfg.MaxDuration, "maximum duration of a crawl"},
+		{"max-crawls", "MAX_CRAWLS", intValue{&cfg.MaxCrawls}, "crawls running at once before the service is not ready"},
+		{"max-diff-size", "MAX_DIFF_SIZE", intValue{&cfg.MaxDiffSize}, "maximum bytes of a /diff request"},
+		{"api-keys-file", "API_KEYS_FILE", stringValue{&cfg.APIKeysFile}, "JSON list of API keys; requests need one of them when set"},
+		{"key-rate-per-minute", "KEY_RATE_PER_MINUTE", floatValue{&cfg.KeyRatePerMinute}, "requests each API key may send per minute"},
+		{"key-max-crawls", "KEY_MAX_CRAWLS", intValue{&cfg.KeyMaxCrawls}, "crawls each API key may run at once"},
+		{"search-index", "SEARCH_INDEX", boolValue{&cfg.SearchIndex}, "index crawled pages for /search"},
+		{"search-index-file", "SEARCH_INDEX_FILE", stringValue{&cfg.SearchIndexFile}, "keep the search index in this file, implies search-index"},
+	}
+}
+
+// loadConfig reads the configuration from the config file, the environment
+// and args, in that order, so later sources win.
+func loadConfig(args []string) (Config, error) {
+	cfg := defaultConfig()
+
+	configFile := os.Getenv("CONFIG_FILE")
+	if err := cfg.flagSet(&configFile).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if configFile != "" {
+		data, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("Failed to read config file ( %s ). { %v }", configFile, err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("Failed to parse config file ( %s ). { %v }", configFile, err)
+		}
+	}
+
+	for _, option := range cfg.options() {
+		if value, ok := os.LookupEnv(option.env); ok {
+			if err := option.value.Set(value); err != nil {
+				return Config{}, fmt.Errorf("Failed to parse %s ( %s ). { %v }", option.env, value, err)
+			}
+		}
+	}
+
+	// Parse the flags again so they override the file and the environment.
+	if err := cfg.flagSet(&configFile).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (cfg *Config) flagSet(configFile *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("crawlapp", flag.ContinueOnError)
+	fs.StringVar(configFile, "config", *configFile, "JSON config file")
+	for _, option := range cfg.options() {
+		fs.Var(option.value, option.flag, fmt.Sprintf("%s (%s)", option.usage, option.env))
+	}
+	return fs
+}
+
+func (cfg Config) validate() error {
+	if cfg.MaxDepth < 1 || cfg.DefaultDepth < 1 || cfg.DefaultDepth > cfg.MaxDepth {
+		return fmt.Errorf("Default depth ( %d ) must be between 1 and max depth ( %d )", cfg.DefaultDepth, cfg.MaxDepth)
+	}
+
+	if cfg.MaxPages < 1 || cfg.MaxConcurrency < 1 || cfg.MaxDuration <= 0 || cfg.MaxCrawls < 1 || cfg.MaxDiffSize < 1 {
+		return fmt.Errorf("Max pages, max concurrency, max duration, max crawls and max diff size must be positive")
+	}
+
+	if cfg.KeyRatePerMinute <= 0 || cfg.KeyMaxCrawls < 1 {
+		return fmt.Errorf("Key rate per minute and key max crawls must be positive")
+	}
+
+	if time.Duration(cfg.WriteTimeout) <= time.Duration(cfg.MaxDuration) {
+		return fmt.Errorf("Write timeout ( %s ) must be longer than max duration ( %s )", cfg.WriteTimeout, cfg.MaxDuration)
+	}
+
+	return nil
+}
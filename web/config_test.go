@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawler-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config.json")
+	config := `{"port": "9000", "max_pages": 50, "max_crawls": 4, "cache_ttl": "10m"}`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		check   func(cfg Config) bool
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			check: func(cfg Config) bool { return cfg.Port == "8080" && cfg.MaxPages == maxCrawlPages },
+		},
+		{
+			name: "file",
+			args: []string{"-config", configFile},
+			check: func(cfg Config) bool {
+				return cfg.Port == "9000" && cfg.MaxPages == 50 && cfg.CacheTTL == Duration(10*time.Minute)
+			},
+		},
+		{
+			name:  "file from the environment",
+			env:   map[string]string{"CONFIG_FILE": configFile},
+			check: func(cfg Config) bool { return cfg.Port == "9000" },
+		},
+		{
+			name:  "environment over file",
+			env:   map[string]string{"CONFIG_FILE": configFile, "PORT": "9001", "BLOCKED_CIDRS": "10.0.0.0/8, 192.168.0.0/16"},
+			check: func(cfg Config) bool { return cfg.Port == "9001" && cfg.MaxPages == 50 && len(cfg.BlockedCIDRs) == 2 },
+		},
+		{
+			name: "flags over environment and file",
+			env:  map[string]string{"PORT": "9001", "MAX_CRAWLS": "8"},
+			args: []string{"-config", configFile, "-port", "9002", "-search-index"},
+			check: func(cfg Config) bool {
+				return cfg.Port == "9002" && cfg.MaxCrawls == 8 && cfg.MaxPages == 50 && cfg.SearchIndex
+			},
+		},
+		{
+			name:    "missing file",
+			args:    []string{"-config", filepath.Join(dir, "missing.json")},
+			wantErr: true,
+		},
+		{
+			name:    "bad environment",
+			env:     map[string]string{"MAX_PAGES": "many"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"-pages", "10"},
+			wantErr: true,
+		},
+		{
+			name:    "default depth over max depth",
+			args:    []string{"-default-depth", "4", "-max-depth", "3"},
+			wantErr: true,
+		},
+		{
+			name:  "diff size",
+			env:   map[string]string{"MAX_DIFF_SIZE": "1048576"},
+			check: func(cfg Config) bool { return cfg.MaxDiffSize == 1<<20 },
+		},
+		{
+			name:    "no diff size",
+			args:    []string{"-max-diff-size", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "no pages",
+			args:    []string{"-max-pages", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "no key rate",
+			env:     map[string]string{"KEY_RATE_PER_MINUTE": "0"},
+			wantErr: true,
+		},
+		{
+			name:    "write timeout under max duration",
+			args:    []string{"-write-timeout", "1m", "-max-duration", "2m"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			cfg, err := loadConfig(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(cfg) {
+				t.Errorf("loadConfig() = %+v", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ariefrahmansyah/crawler"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// Defaults and limits of what a caller of /crawl may ask for.
+var defaultCrawlDepth = 2
+var maxCrawlDepth = 5
+var maxCrawlPages = 1000
+var maxCrawlConcurrency = 16
+var maxCrawlDuration = 5 * time.Minute
+var maxRequestSize int64 = 1 << 20
+
+// CrawlRequest is the JSON body of POST /crawl with the application/json
+// content type. Only site is required.
+//
+//	{
+//	  "site": "https://example.com",   // http or https URL to start from
+//	  "max_depth": 2,                   // 1 to maxCrawlDepth, defaultCrawlDepth when omitted
+//	  "max_pages": 100,                 // 1 to maxCrawlPages, maxCrawlPages when omitted
+//	  "max_bytes": 10485760,            // no limit when omitted
+//	  "max_pages_per_host": 50,         // no limit when omitted
+//	  "max_duration": "30s",            // Go duration up to maxCrawlDuration
+//	  "include": ["^https://example.com/docs/"],
+//	  "exclude": ["\\.pdf$"],           // regular expressions on link URLs
+//	  "concurrency": 8,                 // 0 to maxCrawlConcurrency, 0 or omitted for 8
+//	  "accessibility": true,            // lint pages for accessibility issues
+//	  "mixed_content": true,            // find HTTP resources of HTTPS pages
+//	  "format": "json"                 // json, dot, graphml, csv (pages), csv-links,
+//	                                   // html (report), audit, audit-html (SEO),
+//	                                   // analytics (inbound links, PageRank, hubs),
+//	                                   // duplicates (groups of duplicate pages),
+//	                                   // or mixed-content (HTTP resources of HTTPS pages)
+//	}
+//
+// GET /crawl and form posts take the same fields as query parameters or form
+// fields. Crawls are breadth first, so every page is fetched once and the
+// limits apply. When the search index is enabled, crawled pages are added to
+// it for /search.
+type CrawlRequest struct {
+	Site            string   `json:"site"`
+	MaxDepth        int      `json:"max_depth"`
+	MaxPages        int      `json:"max_pages"`
+	MaxBytes        int64    `json:"max_bytes"`
+	MaxPagesPerHost int      `json:"max_pages_per_host"`
+	MaxDuration     string   `json:"max_duration"`
+	Include         []string `json:"include"`
+	Exclude         []string `json:"exclude"`
+	Concurrency     int      `json:"concurrency"`
+	Accessibility   bool     `json:"accessibility"`
+	MixedContent    bool     `json:"mixed_content"`
+	Format          string   `json:"format"`
+}
+
+// resultWriter writes a crawl result in one of the formats of CrawlRequest.
+type resultWriter struct {
+	contentType string
+	write       func(w io.Writer, result crawler.CrawlResult) error
+}
+
+// findResultWriter returns the writer of format: the JSON of the crawl
+// result, with why the crawl stopped and its broken and trapped URLs, or a
+// format of the library.
+func findResultWriter(format string) (resultWriter, bool) {
+	if format == "json" {
+		return resultWriter{"application/json", func(w io.Writer, result crawler.CrawlResult) error {
+			return json.NewEncoder(w).Encode(result)
+		}}, true
+	}
+
+	resultFormat, ok := crawler.FindResultFormat(format)
+	return resultWriter{resultFormat.ContentType, resultFormat.Write}, ok
+}
+
+// ErrorResponse is the body of every error answered by the API.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes what went wrong. Field names the request field at fault,
+// if any.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// Error codes of APIError.
+const (
+	errorMalformedRequest = "malformed_request"
+	errorInvalidField     = "invalid_field"
+	errorMethodNotAllowed = "method_not_allowed"
+	errorCrawlFailed      = "crawl_failed"
+	errorShuttingDown     = "shutting_down"
+	errorTooManyCrawls    = "too_many_crawls"
+	errorUnauthorized     = "unauthorized"
+	errorRateLimited      = "rate_limited"
+	errorQuotaExceeded    = "quota_exceeded"
+	errorSearchDisabled   = "search_disabled"
+	errorInternal         = "internal_error"
+)
+
+func writeError(w http.ResponseWriter, status int, apiError APIError) {
+	body, err := json.Marshal(ErrorResponse{Error: apiError})
+	if err != nil {
+		log.Errorf("Failed to marshal error ( %v ). { %s }", apiError, err)
+		http.Error(w, apiError.Message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// fieldError is a request field with a value the API doesn't accept.
+type fieldError struct {
+	field   string
+	message string
+}
+
+func (err *fieldError) Error() string {
+	return fmt.Sprintf("%s: %s", err.field, err.message)
+}
+
+// decodeCrawlRequest reads a CrawlRequest from the body of a POST with the
+// application/json content type, or else from the query parameters and form
+// fields of the request.
+func decodeCrawlRequest(r *http.Request) (CrawlRequest, error) {
+	var crawlRequest CrawlRequest
+
+	if r.Method == http.MethodPost && isJSON(r) {
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&crawlRequest); err != nil {
+			return CrawlRequest{}, err
+		}
+		return crawlRequest, nil
+	}
+
+	r.Body = http.MaxBytesReader(nil, r.Body, maxRequestSize)
+	if err := r.ParseForm(); err != nil {
+		return CrawlRequest{}, err
+	}
+	query := r.Form
+	crawlRequest.Site = query.Get("site")
+	crawlRequest.MaxDuration = query.Get("max_duration")
+	crawlRequest.Format = query.Get("format")
+	crawlRequest.Include = query["include"]
+	crawlRequest.Exclude = query["exclude"]
+
+	ints := []struct {
+		field string
+		value *int
+	}{
+		{"max_depth", &crawlRequest.MaxDepth},
+		{"max_pages", &crawlRequest.MaxPages},
+		{"max_pages_per_host", &crawlRequest.MaxPagesPerHost},
+		{"concurrency", &crawlRequest.Concurrency},
+	}
+	for _, i := range ints {
+		if value := query.Get(i.field); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return CrawlRequest{}, &fieldError{i.field, "must be an integer"}
+			}
+			*i.value = n
+		}
+	}
+
+	if value := query.Get("max_bytes"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return CrawlRequest{}, &fieldError{"max_bytes", "must be an integer"}
+		}
+		crawlRequest.MaxBytes = n
+	}
+
+	if value := query.Get("accessibility"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return CrawlRequest{}, &fieldError{"accessibility", "must be a boolean"}
+		}
+		crawlRequest.Accessibility = b
+	}
+
+	if value := query.Get("mixed_content"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return CrawlRequest{}, &fieldError{"mixed_content", "must be a boolean"}
+		}
+		crawlRequest.MixedContent = b
+	}
+
+	return crawlRequest, nil
+}
+
+// isJSON tells whether the body of r is JSON, by its content type.
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+func (crawlRequest CrawlRequest) format() string {
+	if crawlRequest.Format == "" {
+		return "json"
+	}
+	return crawlRequest.Format
+}
+
+// validate turns the request into a query and crawler options, applying the
+// defaults and checking the limits of the API.
+func (crawlRequest CrawlRequest) validate() (crawler.CrawlQuery, crawler.CrawlerOpt, error) {
+	siteURL, err := url.Parse(crawlRequest.Site)
+	if crawlRequest.Site == "" {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"site", "is required"}
+	}
+	if err != nil || (siteURL.Scheme != "http" && siteURL.Scheme != "https") || siteURL.Host == "" {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"site", "must be an http or https URL"}
+	}
+
+	query := crawler.CrawlQuery{
+		Site:            crawlRequest.Site,
+		MaxDepth:        crawlRequest.MaxDepth,
+		MaxPages:        crawlRequest.MaxPages,
+		MaxBytes:        crawlRequest.MaxBytes,
+		MaxPagesPerHost: crawlRequest.MaxPagesPerHost,
+		Include:         crawlRequest.Include,
+		Exclude:         crawlRequest.Exclude,
+	}
+
+	if query.MaxDepth == 0 {
+		query.MaxDepth = defaultCrawlDepth
+	}
+	if query.MaxDepth < 1 || query.MaxDepth > maxCrawlDepth {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"max_depth", fmt.Sprintf("must be between 1 and %d", maxCrawlDepth)}
+	}
+
+	if query.MaxPages == 0 {
+		query.MaxPages = maxCrawlPages
+	}
+	if query.MaxPages < 1 || query.MaxPages > maxCrawlPages {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"max_pages", fmt.Sprintf("must be between 1 and %d", maxCrawlPages)}
+	}
+
+	if query.MaxBytes < 0 {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"max_bytes", "must not be negative"}
+	}
+
+	if query.MaxPagesPerHost < 0 {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"max_pages_per_host", "must not be negative"}
+	}
+
+	query.MaxDuration = maxCrawlDuration
+	if crawlRequest.MaxDuration != "" {
+		maxDuration, err := time.ParseDuration(crawlRequest.MaxDuration)
+		if err != nil || maxDuration <= 0 || maxDuration > maxCrawlDuration {
+			return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"max_duration", fmt.Sprintf("must be a duration up to %s", maxCrawlDuration)}
+		}
+		query.MaxDuration = maxDuration
+	}
+
+	for _, pattern := range query.Include {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"include", fmt.Sprintf("%q is not a valid regular expression", pattern)}
+		}
+	}
+	for _, pattern := range query.Exclude {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"exclude", fmt.Sprintf("%q is not a valid regular expression", pattern)}
+		}
+	}
+
+	if crawlRequest.Concurrency < 0 || crawlRequest.Concurrency > maxCrawlConcurrency {
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"concurrency", fmt.Sprintf("must be between 0 and %d, 0 for the default", maxCrawlConcurrency)}
+	}
+
+	if _, ok := findResultWriter(crawlRequest.format()); !ok {
+		formats := append([]string{"json"}, crawler.ResultFormatNames()...)
+		return crawler.CrawlQuery{}, crawler.CrawlerOpt{}, &fieldError{"format", "must be one of " + strings.Join(formats, ", ")}
+	}
+
+	crawlerOpt := crawler.CrawlerOpt{
+		Strategy:      crawler.BreadthFirst,
+		Concurrency:   crawlRequest.Concurrency,
+		Accessibility: crawlRequest.Accessibility,
+		Fingerprints:  crawlRequest.format() == "duplicates",
+		MixedContent:  crawlRequest.MixedContent || crawlRequest.format() == "mixed-content",
+		Index:         searchIndex,
+		Traps:         crawler.DefaultTrapOpt,
+		AddressGuard:  addressGuard,
+		// Anyone can ask for any site, so fetch durations aren't labelled
+		// by host: HostMetrics stays off.
+		Registerer: prometheus.DefaultRegisterer,
+		Cache:      siteCache,
+		CacheTTL:   siteCacheTTL,
+	}
+
+	return query, crawlerOpt, nil
+}
+
+// CrawlHandler crawls the site described by a CrawlRequest and answers the
+// crawl result in the format of the request, JSON by default. Malformed requests
+// get 400, requests the API doesn't allow get 422 and failed crawls get 500.
+// Crawls asked for while the server shuts down, or runs maxCrawls crawls, get
+// 503. Every error is an ErrorResponse.
+func CrawlHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPost}, ", "))
+		writeError(w, http.StatusMethodNotAllowed, APIError{Code: errorMethodNotAllowed, Message: "Use GET or POST"})
+		return
+	}
+
+	if isShuttingDown() {
+		writeError(w, http.StatusServiceUnavailable, APIError{Code: errorShuttingDown, Message: "Server is shutting down"})
+		return
+	}
+
+	crawlRequest, err := decodeCrawlRequest(r)
+	if err != nil {
+		apiError := APIError{Code: errorMalformedRequest, Message: err.Error()}
+		if fieldErr, ok := err.(*fieldError); ok {
+			apiError.Field, apiError.Message = fieldErr.field, fieldErr.message
+		}
+		writeError(w, http.StatusBadRequest, apiError)
+		return
+	}
+
+	crawlQuery, crawlerOpt, err := crawlRequest.validate()
+	if err != nil {
+		fieldErr := err.(*fieldError)
+		writeError(w, http.StatusUnprocessableEntity, APIError{Code: errorInvalidField, Message: fieldErr.message, Field: fieldErr.field})
+		return
+	}
+
+	crawl := crawler.NewCrawler(ctx, crawlerOpt)
+
+	job, ok := crawlJobs.start(crawlQuery.Site, crawl)
+	if !ok {
+		w.Header().Set("Retry-After", "10")
+		writeError(w, http.StatusServiceUnavailable, APIError{Code: errorTooManyCrawls, Message: "Too many crawls are running"})
+		return
+	}
+	defer crawlJobs.finish(job)
+
+	result, err := crawl.CrawlBreadthFirst(ctx, crawlQuery)
+	if err != nil {
+		log.Errorf("Failed to crawl ( %v ). { %s }", crawlQuery, err)
+		writeError(w, http.StatusInternalServerError, APIError{Code: errorCrawlFailed, Message: err.Error()})
+		return
+	}
+
+	saveSearchIndex()
+
+	writer, _ := findResultWriter(crawlRequest.format())
+
+	var buf bytes.Buffer
+	if err := writer.write(&buf, result); err != nil {
+		log.Errorf("Failed to write crawl result as %s ( %s ). { %s }", crawlRequest.format(), crawlQuery.Site, err)
+		writeError(w, http.StatusInternalServerError, APIError{Code: errorInternal, Message: "Failed to write crawl result"})
+		return
+	}
+
+	w.Header().Set("Content-Type", writer.contentType)
+	buf.WriteTo(w)
+}
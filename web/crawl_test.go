@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ariefrahmansyah/crawler"
+)
+
+func TestDecodeCrawlRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		want        CrawlRequest
+		wantErr     string
+	}{
+		{
+			name:        "json body",
+			method:      http.MethodPost,
+			target:      "/crawl",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"site": "https://monzo.com", "max_depth": 3, "max_bytes": 1024, "include": ["^https://monzo.com/"], "mixed_content": true, "format": "dot"}`,
+			want:        CrawlRequest{Site: "https://monzo.com", MaxDepth: 3, MaxBytes: 1024, Include: []string{"^https://monzo.com/"}, MixedContent: true, Format: "dot"},
+		},
+		{
+			name:   "query parameters",
+			method: http.MethodGet,
+			target: "/crawl?site=https://monzo.com&max_depth=3&max_bytes=1024&include=^https://monzo.com/&mixed_content=true&format=dot",
+			want:   CrawlRequest{Site: "https://monzo.com", MaxDepth: 3, MaxBytes: 1024, Include: []string{"^https://monzo.com/"}, MixedContent: true, Format: "dot"},
+		},
+		{
+			name:        "form post",
+			method:      http.MethodPost,
+			target:      "/crawl",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "site=https://monzo.com&max_depth=3&accessibility=true",
+			want:        CrawlRequest{Site: "https://monzo.com", MaxDepth: 3, Accessibility: true},
+		},
+		{
+			name:   "post without a body",
+			method: http.MethodPost,
+			target: "/crawl?site=https://monzo.com&max_depth=3",
+			want:   CrawlRequest{Site: "https://monzo.com", MaxDepth: 3},
+		},
+		{
+			name:        "unknown json field",
+			method:      http.MethodPost,
+			target:      "/crawl",
+			contentType: "application/json",
+			body:        `{"site": "https://monzo.com", "depth": 3}`,
+			wantErr:     `json: unknown field "depth"`,
+		},
+		{
+			name:        "malformed json",
+			method:      http.MethodPost,
+			target:      "/crawl",
+			contentType: "application/json",
+			body:        `{"site": `,
+			wantErr:     "unexpected EOF",
+		},
+		{
+			name:    "integer parameter",
+			method:  http.MethodGet,
+			target:  "/crawl?site=https://monzo.com&max_pages=many",
+			wantErr: "max_pages: must be an integer",
+		},
+		{
+			name:    "boolean parameter",
+			method:  http.MethodGet,
+			target:  "/crawl?site=https://monzo.com&accessibility=maybe",
+			wantErr: "accessibility: must be a boolean",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			got, err := decodeCrawlRequest(r)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("decodeCrawlRequest() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCrawlRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCrawlRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlRequest_validate(t *testing.T) {
+	site := "https://monzo.com"
+
+	tests := []struct {
+		name         string
+		crawlRequest CrawlRequest
+		wantField    string
+	}{
+		{"defaults", CrawlRequest{Site: site}, ""},
+		{"default concurrency", CrawlRequest{Site: site, Concurrency: 0}, ""},
+		{"max concurrency", CrawlRequest{Site: site, Concurrency: maxCrawlConcurrency}, ""},
+		{"no site", CrawlRequest{}, "site"},
+		{"not http", CrawlRequest{Site: "ftp://monzo.com"}, "site"},
+		{"no host", CrawlRequest{Site: "https://"}, "site"},
+		{"too deep", CrawlRequest{Site: site, MaxDepth: maxCrawlDepth + 1}, "max_depth"},
+		{"negative depth", CrawlRequest{Site: site, MaxDepth: -1}, "max_depth"},
+		{"too many pages", CrawlRequest{Site: site, MaxPages: maxCrawlPages + 1}, "max_pages"},
+		{"negative bytes", CrawlRequest{Site: site, MaxBytes: -1}, "max_bytes"},
+		{"negative pages per host", CrawlRequest{Site: site, MaxPagesPerHost: -1}, "max_pages_per_host"},
+		{"bad duration", CrawlRequest{Site: site, MaxDuration: "soon"}, "max_duration"},
+		{"too long", CrawlRequest{Site: site, MaxDuration: (maxCrawlDuration + 1).String()}, "max_duration"},
+		{"bad include", CrawlRequest{Site: site, Include: []string{"("}}, "include"},
+		{"bad exclude", CrawlRequest{Site: site, Exclude: []string{"["}}, "exclude"},
+		{"negative concurrency", CrawlRequest{Site: site, Concurrency: -1}, "concurrency"},
+		{"too much concurrency", CrawlRequest{Site: site, Concurrency: maxCrawlConcurrency + 1}, "concurrency"},
+		{"unknown format", CrawlRequest{Site: site, Format: "pdf"}, "format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _, err := tt.crawlRequest.validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("CrawlRequest.validate() error = %v", err)
+				}
+				if query.MaxDepth != defaultCrawlDepth || query.MaxPages != maxCrawlPages || query.MaxDuration != maxCrawlDuration {
+					t.Errorf("CrawlRequest.validate() = %+v, want the default limits", query)
+				}
+				return
+			}
+
+			fieldErr, ok := err.(*fieldError)
+			if !ok || fieldErr.field != tt.wantField {
+				t.Errorf("CrawlRequest.validate() error = %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestCrawlHandler_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+		error  APIError
+	}{
+		{"method", http.MethodPut, "/crawl", "", http.StatusMethodNotAllowed,
+			APIError{Code: errorMethodNotAllowed, Message: "Use GET or POST"}},
+		{"unknown field", http.MethodPost, "/crawl", `{"site": "https://monzo.com", "depth": 3}`, http.StatusBadRequest,
+			APIError{Code: errorMalformedRequest, Message: `json: unknown field "depth"`}},
+		{"malformed parameter", http.MethodGet, "/crawl?site=https://monzo.com&concurrency=many", "", http.StatusBadRequest,
+			APIError{Code: errorMalformedRequest, Message: "must be an integer", Field: "concurrency"}},
+		{"invalid field", http.MethodPost, "/crawl", `{"site": "https://monzo.com", "concurrency": 17}`, http.StatusUnprocessableEntity,
+			APIError{Code: errorInvalidField, Message: "must be between 0 and 16, 0 for the default", Field: "concurrency"}},
+		{"missing site", http.MethodGet, "/crawl", "", http.StatusUnprocessableEntity,
+			APIError{Code: errorInvalidField, Message: "is required", Field: "site"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			CrawlHandler(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("CrawlHandler() status = %v, want %v", w.Code, tt.want)
+			}
+
+			var got ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Failed to decode error response. { %v }", err)
+			}
+			if got.Error != tt.error {
+				t.Errorf("CrawlHandler() error = %+v, want %+v", got.Error, tt.error)
+			}
+		})
+	}
+}
+
+func TestCrawlHandler_json(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/about">About</a><a href="/team">Team</a></body></html>`))
+	}))
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	CrawlHandler(w, httptest.NewRequest(http.MethodGet, "/crawl?max_pages=1&site="+server.URL, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("CrawlHandler() status = %v, want %v. %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var got crawler.CrawlResult
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode crawl result. { %v }", err)
+	}
+	if got.Pages != 1 || got.StoppedBy != crawler.LimitPages || len(got.Unvisited) != 2 {
+		t.Errorf("CrawlHandler() = %d pages, stopped by %q with %v unvisited, want 1 page stopped by %q with 2 unvisited", got.Pages, got.StoppedBy, got.Unvisited, crawler.LimitPages)
+	}
+}
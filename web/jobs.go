@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ariefrahmansyah/crawler"
+	log "github.com/sirupsen/logrus"
+)
+
+var maxCrawls = 16
+
+// crawlJob is a crawl running in a request.
+type crawlJob struct {
+	id      int64
+	site    string
+	started time.Time
+	crawler *crawler.Crawler
+}
+
+// jobRegistry holds the running crawls. At most maxCrawls run at once.
+type jobRegistry struct {
+	mutex  *sync.Mutex
+	nextID int64
+	jobs   map[int64]*crawlJob
+}
+
+var crawlJobs = &jobRegistry{
+	mutex: &sync.Mutex{},
+	jobs:  make(map[int64]*crawlJob),
+}
+
+// start registers a crawl of site. It returns false when maxCrawls are
+// already running.
+func (registry *jobRegistry) start(site string, crawl *crawler.Crawler) (*crawlJob, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if len(registry.jobs) >= maxCrawls {
+		return nil, false
+	}
+
+	registry.nextID++
+	job := &crawlJob{
+		id:      registry.nextID,
+		site:    site,
+		started: time.Now(),
+		crawler: crawl,
+	}
+	registry.jobs[job.id] = job
+
+	return job, true
+}
+
+func (registry *jobRegistry) finish(job *crawlJob) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.jobs, job.id)
+}
+
+func (registry *jobRegistry) running() int {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	return len(registry.jobs)
+}
+
+// saturated tells whether no more crawls can start.
+func (registry *jobRegistry) saturated() bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	return len(registry.jobs) >= maxCrawls
+}
+
+// CrawlStatus is a running crawl as listed by /debug/crawls.
+type CrawlStatus struct {
+	ID      int64     `json:"id"`
+	Site    string    `json:"site"`
+	Started time.Time `json:"started"`
+	Elapsed string    `json:"elapsed"`
+	Pages   int       `json:"pages"`
+	Failed  int       `json:"failed"`
+	Queued  int       `json:"queued"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// list returns the status of every running crawl, oldest first.
+func (registry *jobRegistry) list() []CrawlStatus {
+	registry.mutex.Lock()
+	jobs := make([]*crawlJob, 0, len(registry.jobs))
+	for _, job := range registry.jobs {
+		jobs = append(jobs, job)
+	}
+	registry.mutex.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].id < jobs[j].id })
+
+	now := time.Now()
+	statuses := make([]CrawlStatus, 0, len(jobs))
+	for _, job := range jobs {
+		progress := job.crawler.Progress()
+		statuses = append(statuses, CrawlStatus{
+			ID:      job.id,
+			Site:    job.site,
+			Started: job.started,
+			Elapsed: now.Sub(job.started).Round(time.Millisecond).String(),
+			Pages:   progress.Pages,
+			Failed:  progress.Failed,
+			Queued:  progress.Queued,
+			Bytes:   progress.Bytes,
+		})
+	}
+
+	return statuses
+}
+
+// HealthHandler answers 200 while the process is up.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// Readiness is the body of /readyz.
+type Readiness struct {
+	Ready     bool   `json:"ready"`
+	Reason    string `json:"reason,omitempty"`
+	Crawls    int    `json:"crawls"`
+	MaxCrawls int    `json:"max_crawls"`
+}
+
+// ReadyHandler answers 200 when the service can take a crawl, and 503 while
+// it shuts down or runs as many crawls as it may.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	readiness := Readiness{Ready: true, Crawls: crawlJobs.running(), MaxCrawls: maxCrawls}
+
+	switch {
+	case isShuttingDown():
+		readiness.Ready, readiness.Reason = false, "shutting down"
+	case crawlJobs.saturated():
+		readiness.Ready, readiness.Reason = false, "crawl queue is full"
+	}
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(readiness)
+}
+
+var crawlsTemplate = template.Must(template.New("crawls").Parse(`<!DOCTYPE html>
+<html><head><title>Crawls</title></head><body>
+<h1>{{len .}} running crawls</h1>
+<table border="1" cellpadding="4">
+<tr><th>ID</th><th>Site</th><th>Elapsed</th><th>Pages</th><th>Failed</th><th>Queued</th><th>Bytes</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.Site}}</td><td>{{.Elapsed}}</td><td>{{.Pages}}</td><td>{{.Failed}}</td><td>{{.Queued}}</td><td>{{.Bytes}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// CrawlsHandler lists the running crawls as an HTML table, or as JSON when
+// format is json.
+func CrawlsHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := crawlJobs.list()
+
+	if r.FormValue("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statuses)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := crawlsTemplate.Execute(w, statuses); err != nil {
+		log.Errorf("Failed to render crawls. { %s }", err)
+	}
+}
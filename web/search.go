@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ariefrahmansyah/crawler"
+	log "github.com/sirupsen/logrus"
+)
+
+// Limits of the results of /search.
+var defaultSearchLimit = 10
+var maxSearchLimit = 100
+
+// searchIndex gets the pages of every crawl when search is enabled, and is
+// nil otherwise. It is saved to searchIndexFile, when set, after each crawl.
+var searchIndex *crawler.Index
+var searchIndexFile string
+var searchIndexMutex = &sync.Mutex{}
+
+// SearchResponse is the body answered by /search.
+type SearchResponse struct {
+	Query   string                 `json:"query"`
+	Results []crawler.SearchResult `json:"results"`
+}
+
+// openSearchIndex loads the index saved to file, or returns an empty one when
+// there is no file yet.
+func openSearchIndex(file string) (*crawler.Index, error) {
+	if file == "" {
+		return crawler.NewIndex(), nil
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return crawler.NewIndex(), nil
+	}
+
+	return crawler.LoadIndex(file)
+}
+
+// saveSearchIndex saves the search index to searchIndexFile, if both are set.
+// Saves are serialized so the last one has the latest pages.
+func saveSearchIndex() {
+	if searchIndex == nil || searchIndexFile == "" {
+		return
+	}
+
+	searchIndexMutex.Lock()
+	defer searchIndexMutex.Unlock()
+
+	if err := searchIndex.Save(searchIndexFile); err != nil {
+		log.Errorf("Failed to save search index ( %s ). { %s }", searchIndexFile, err)
+	}
+}
+
+// SearchHandler searches the pages of earlier crawls for the words of q and
+// answers a SearchResponse with the best limit pages, 10 by default. It
+// answers 404 when the search index isn't enabled.
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, APIError{Code: errorMethodNotAllowed, Message: "Use GET"})
+		return
+	}
+
+	if searchIndex == nil {
+		writeError(w, http.StatusNotFound, APIError{Code: errorSearchDisabled, Message: "Search index is not enabled"})
+		return
+	}
+
+	query := r.URL.Query()
+	q := query.Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, APIError{Code: errorInvalidField, Message: "is required", Field: "q"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			writeError(w, http.StatusBadRequest, APIError{Code: errorInvalidField, Message: "must be between 1 and " + strconv.Itoa(maxSearchLimit), Field: "limit"})
+			return
+		}
+		limit = n
+	}
+
+	body, err := json.Marshal(SearchResponse{Query: q, Results: searchIndex.Search(q, limit)})
+	if err != nil {
+		log.Errorf("Failed to marshal search results ( %s ). { %s }", q, err)
+		writeError(w, http.StatusInternalServerError, APIError{Code: errorInternal, Message: "Failed to marshal search results"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
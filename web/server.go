@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// shuttingDown is set once the server got a signal to stop. New crawls are
+// refused from then on.
+var shuttingDown int32
+
+func isShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
+// serve runs handler until SIGINT or SIGTERM. Then it stops accepting
+// connections and waits up to cfg.ShutdownTimeout for in-flight requests.
+// Crawls still running after that are cancelled.
+func serve(cfg Config, handler http.Handler) error {
+	listener, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	return serveUntil(cfg, listener, handler, signals)
+}
+
+// serveUntil runs handler on listener until a signal arrives on stop, then
+// shuts down like serve.
+func serveUntil(cfg Config, listener net.Listener, handler http.Handler, stop <-chan os.Signal) error {
+	// Every request context derives from baseCtx, so cancelling it cancels
+	// every in-flight crawl.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.IdleTimeout),
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Infof("App started at port: %s", cfg.Port)
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-stop:
+		log.Infof("Got %s. Shutting down within %s", sig, cfg.ShutdownTimeout)
+	}
+
+	atomic.StoreInt32(&shuttingDown, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Warnf("Crawls did not finish in time. Cancelling them. { %s }", err)
+		cancelRequests()
+
+		// Give the cancelled handlers a moment to answer before closing
+		// their connections.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			return server.Close()
+		}
+	}
+
+	log.Infof("App stopped")
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServeUntil(t *testing.T) {
+	tests := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		work            time.Duration
+		want            string
+	}{
+		{"finishes in the grace period", 5 * time.Second, 50 * time.Millisecond, "done"},
+		{"cancelled after the grace period", 50 * time.Millisecond, time.Minute, "cancelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer atomic.StoreInt32(&shuttingDown, 0)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			started := make(chan struct{})
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.work):
+					w.Write([]byte("done"))
+				case <-r.Context().Done():
+					w.Write([]byte("cancelled"))
+				}
+			})
+
+			cfg := defaultConfig()
+			cfg.ShutdownTimeout = Duration(tt.shutdownTimeout)
+
+			stop := make(chan os.Signal, 1)
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- serveUntil(cfg, listener, handler, stop)
+			}()
+
+			body := make(chan string, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String())
+				if err != nil {
+					body <- err.Error()
+					return
+				}
+				defer resp.Body.Close()
+				b, _ := ioutil.ReadAll(resp.Body)
+				body <- string(b)
+			}()
+
+			<-started
+			stop <- syscall.SIGTERM
+
+			if got := <-body; got != tt.want {
+				t.Errorf("in-flight request = %q, want %q", got, tt.want)
+			}
+			if err := <-stopped; err != nil {
+				t.Errorf("serveUntil() error = %v", err)
+			}
+			if !isShuttingDown() {
+				t.Errorf("isShuttingDown() = false after the signal")
+			}
+		})
+	}
+}
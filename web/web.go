@@ -2,24 +2,84 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
+	"io"
 	"net/http"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/ariefrahmansyah/crawler"
 	promnegroni "github.com/ariefrahmansyah/negroni-prometheus"
 	"github.com/prometheus/client_golang/prometheus"
+	promlog "github.com/prometheus/common/log"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/negroni"
 )
 
+// addressGuard keeps crawls away from internal addresses. It is nil when
+// private networks are allowed.
+var addressGuard *crawler.AddressGuard
+
+// siteCache is shared by every crawl, so repeated crawls reuse pages. It is
+// kept in the cache directory when set, in memory otherwise.
+var siteCache crawler.Cache
+var siteCacheTTL = time.Hour
+
 func main() {
-	// log.SetLevel(log.DebugLevel)
-	log.SetLevel(log.InfoLevel)
+	cfg, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load config. { %s }", err)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	level, err := log.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Failed to parse log level ( %s ). { %s }", cfg.LogLevel, err)
+	}
+	log.SetLevel(level)
+	if err := promlog.Base().SetLevel(cfg.LogLevel); err != nil {
+		log.Fatalf("Failed to set log level ( %s ). { %s }", cfg.LogLevel, err)
+	}
+
+	if !cfg.AllowPrivateNetworks {
+		addressGuard, err = crawler.NewAddressGuard(cfg.BlockedCIDRs...)
+		if err != nil {
+			log.Fatalf("Failed to create address guard. { %s }", err)
+		}
+	}
+
+	siteCache = crawler.NewMemoryCache(10000)
+	if cfg.CacheDir != "" {
+		fileCache, err := crawler.NewFileCache(cfg.CacheDir)
+		if err != nil {
+			log.Fatalf("Failed to create cache. { %s }", err)
+		}
+		siteCache = fileCache
+
+		// Entries never expire without a TTL.
+		if cfg.CacheTTL > 0 {
+			go pruneCache(fileCache, time.Duration(cfg.CacheTTL))
+		}
+	}
+	siteCacheTTL = time.Duration(cfg.CacheTTL)
+
+	defaultCrawlDepth = cfg.DefaultDepth
+	maxCrawlDepth = cfg.MaxDepth
+	maxCrawlPages = cfg.MaxPages
+	maxCrawlConcurrency = cfg.MaxConcurrency
+	maxCrawlDuration = time.Duration(cfg.MaxDuration)
+	maxCrawls = cfg.MaxCrawls
+	maxDiffSize = int64(cfg.MaxDiffSize)
+
+	if cfg.SearchIndex || cfg.SearchIndexFile != "" {
+		searchIndex, err = openSearchIndex(cfg.SearchIndexFile)
+		if err != nil {
+			log.Fatalf("Failed to open search index. { %s }", err)
+		}
+		searchIndexFile = cfg.SearchIndexFile
+		log.Infof("Search index enabled with %d pages", searchIndex.Len())
 	}
 
 	mux := http.NewServeMux()
@@ -28,47 +88,102 @@ func main() {
 	// crawl a web page
 	mux.HandleFunc("/crawl", CrawlHandler)
 
+	// compare two crawls
+	mux.HandleFunc("/diff", DiffHandler)
+
+	// search crawled pages
+	mux.HandleFunc("/search", SearchHandler)
+
+	// liveness, readiness and running crawls
+	mux.HandleFunc("/healthz", HealthHandler)
+	mux.HandleFunc("/readyz", ReadyHandler)
+	mux.HandleFunc("/debug/crawls", CrawlsHandler)
+
 	promMiddleware := promnegroni.NewPromMiddleware("crawler", promnegroni.PromMiddlewareOpts{})
 
 	n := negroni.New()
 	n.Use(negroni.NewRecovery())
 	n.Use(negroni.NewLogger())
 	n.Use(promMiddleware)
+
+	if cfg.APIKeysFile != "" {
+		keys, err := loadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			log.Fatalf("Failed to load API keys. { %s }", err)
+		}
+
+		auth, err := NewAuth(keys, cfg.KeyRatePerMinute, cfg.KeyMaxCrawls, prometheus.DefaultRegisterer)
+		if err != nil {
+			log.Fatalf("Failed to create API key auth. { %s }", err)
+		}
+		n.Use(auth)
+		log.Infof("API key auth enabled for %d keys", len(keys))
+	}
+
 	n.UseHandler(mux)
 
-	log.Infof("App started at port: %s", port)
-	http.ListenAndServe(":"+port, n)
+	if err := serve(cfg, n); err != nil {
+		log.Fatalf("Failed to serve. { %s }", err)
+	}
+}
+
+// pruneCache removes the expired entries of cache every ttl, as entries that
+// are never asked for again are never removed by Get.
+func pruneCache(cache *crawler.FileCache, ttl time.Duration) {
+	for range time.Tick(ttl) {
+		removed, err := cache.Prune(time.Now())
+		if err != nil {
+			log.Errorf("Failed to prune cache. { %s }", err)
+			continue
+		}
+		log.Debugf("Pruned %d expired cache entries", removed)
+	}
 }
 
-func CrawlHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+// maxDiffSize is the most bytes a DiffRequest may have. It is larger than
+// maxRequestSize, as it holds two crawl results.
+var maxDiffSize int64 = 32 << 20
 
-	r.ParseForm()
+// DiffRequest is the body of /diff: two crawl results to compare, like the
+// JSON of /crawl.
+type DiffRequest struct {
+	Old crawler.CrawlResult `json:"old"`
+	New crawler.CrawlResult `json:"new"`
+}
 
-	site := r.FormValue("site")
-	maxDepthStr := r.FormValue("max_depth")
-	maxDepth, _ := strconv.Atoi(maxDepthStr)
+// DiffHandler compares two crawls posted as a DiffRequest. It answers JSON, or
+// a text summary when format is text. Bodies over maxDiffSize are cut off
+// and get 400.
+func DiffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, APIError{Code: errorMethodNotAllowed, Message: "Use POST"})
+		return
+	}
 
-	crawlQuery := crawler.CrawlQuery{
-		Site:     site,
-		MaxDepth: maxDepth,
+	var diffRequest DiffRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxDiffSize)).Decode(&diffRequest); err != nil {
+		log.Errorf("Failed to decode diff request. { %s }", err)
+		writeError(w, http.StatusBadRequest, APIError{Code: errorMalformedRequest, Message: err.Error()})
+		return
 	}
 
-	crawl := crawler.NewCrawler(ctx, crawler.CrawlerOpt{})
-	sitemap, err := crawl.Crawl(ctx, crawlQuery, 0)
-	if err != nil {
-		log.Errorf("Failed to crawl ( %v ). { %s }", crawlQuery, err)
-		w.Write([]byte(err.Error()))
+	diff := crawler.DiffResults(diffRequest.Old, diffRequest.New)
+
+	if r.FormValue("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		diff.WriteText(w)
 		return
 	}
 
-	sitemapJSON, err := json.Marshal(sitemap)
+	diffJSON, err := json.Marshal(diff)
 	if err != nil {
-		log.Errorf("Failed to marshal sitemap ( %v ). { %s }", sitemap, err)
-		w.Write([]byte(err.Error()))
+		log.Errorf("Failed to marshal diff ( %d added, %d removed, %d status changes, %d link changes ). { %s }",
+			len(diff.Added), len(diff.Removed), len(diff.StatusChanged), len(diff.LinksChanged), err)
+		writeError(w, http.StatusInternalServerError, APIError{Code: errorInternal, Message: "Failed to marshal diff"})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write(sitemapJSON)
+	w.Write(diffJSON)
 }
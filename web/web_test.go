@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ariefrahmansyah/crawler"
+)
+
+func TestDiffHandler(t *testing.T) {
+	var broken int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/about" && atomic.LoadInt32(&broken) == 1 {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/about">About</a></body></html>`))
+	}))
+	defer server.Close()
+
+	crawl := func() crawler.CrawlResult {
+		result, err := crawler.NewCrawler(context.Background(), crawler.CrawlerOpt{}).CrawlBreadthFirst(context.Background(), crawler.CrawlQuery{Site: server.URL, MaxDepth: 2})
+		if err != nil {
+			t.Fatalf("Crawler.CrawlBreadthFirst() error = %v", err)
+		}
+		return result
+	}
+
+	old := crawl()
+	atomic.StoreInt32(&broken, 1)
+	diffRequest, err := json.Marshal(DiffRequest{Old: old, New: crawl()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"diff", http.MethodPost, string(diffRequest), http.StatusOK},
+		{"method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"malformed", http.MethodPost, `{"old": `, http.StatusBadRequest},
+		{"too large", http.MethodPost, `{"old": {"url": "` + strings.Repeat("a", int(maxDiffSize)) + `"}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			DiffHandler(w, httptest.NewRequest(tt.method, "/diff", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.want {
+				t.Fatalf("DiffHandler() status = %v, want %v", w.Code, tt.want)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var got crawler.SiteDiff
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			want := crawler.SiteDiff{
+				Added:         []string{},
+				Removed:       []string{},
+				StatusChanged: []crawler.StatusChange{{URL: server.URL + "/about", Old: 200, New: 404}},
+				LinksChanged:  []crawler.LinksChange{},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DiffHandler() = %v, want %v", got, want)
+			}
+		})
+	}
+}